@@ -12,11 +12,17 @@ import (
 )
 
 var addr = flag.String("addr", ":8080", "http service address")
+var oidFile = flag.String("oid-file", "", "file of \"<oid> <name>\" lines naming additional OIDs")
 
 var templ = template.Must(template.New("qr").Parse(templateStr))
 
 func main() {
 	flag.Parse()
+	if *oidFile != "" {
+		if err := pki.LoadOIDFile(*oidFile); err != nil {
+			log.Fatal(err)
+		}
+	}
 	http.Handle("/", http.HandlerFunc(CertInfo))
 	err := http.ListenAndServe(*addr, nil)
 	if err != nil {
@@ -39,24 +45,45 @@ func CertInfo(w http.ResponseWriter, req *http.Request) {
 	data := make([]byte, header.Size)
 	file.Read(data)
 
-	blocks := pki.ReadPEMBlocks(data)
-	if len(blocks) == 0 {
-		// Maybe DER
-		blocks = [][]byte{data}
+	decoded, err := pki.DecodeAny(data, req.FormValue("password"))
+	if err != nil {
+		templ.Execute(w, err.Error())
+		return
+	}
+
+	if req.FormValue("format") == "dot" {
+		certs, err := decoded.Certificates()
+		if len(certs) == 0 {
+			templ.Execute(w, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="certificates.dot"`)
+		fmt.Fprint(w, pki.BuildCertTree(certs).DOT())
+		return
 	}
 
-	var certInfos []string
-	for i, b := range blocks {
-		cert, err := pki.TryParseCert(b)
+	certInfos := []string{"Input format:        " + decoded.Format}
+	for i, b := range decoded.Blocks {
+		summary, err := b.Summary()
 		if err != nil {
-			certInfos = append(certInfos, fmt.Sprintf("#%d: not a certificate: %v", i+1, err))
+			certInfos = append(certInfos, fmt.Sprintf("#%d: %s", i+1, b.FormatError(err)))
 			continue
 		}
-		certInfo := fmt.Sprintf("===== Certificate #%d =====\n", i+1)
-		certInfo += pki.GetCertInfoString(cert)
+		certInfo := fmt.Sprintf("===== %s #%d =====\n", b.Kind, i+1)
+		certInfo += summary
 		certInfos = append(certInfos, certInfo)
 	}
 
+	// Bundles are often uploaded out of order; show which certificate
+	// issued which and the chain they make.
+	if certs, _ := decoded.Certificates(); len(certs) > 1 {
+		certInfos = append(certInfos, "===== Certificate Tree =====\n"+pki.BuildCertTree(certs).Text())
+		if res, err := pki.BuildChain(certs, pki.ChainOptions{}); err == nil {
+			certInfos = append(certInfos, "===== Chain =====\n"+res.Text()+"\nOrdered chain:\n"+string(pki.EncodeCertsPEM(res.Chain...)))
+		}
+	}
+
 	// Join all certificate information with double newlines
 	allCertInfo := strings.Join(certInfos, "\n\n")
 	templ.Execute(w, allCertInfo)
@@ -122,16 +149,22 @@ button:hover {
     
     <div class="upload-form">
         <form method="POST" enctype="multipart/form-data" action="/">
-            <label for="cert"><strong>Upload certificate:</strong></label><br>
+            <label for="cert"><strong>Upload certificate, CSR, CRL, key, PKCS#7 or PKCS#12:</strong></label><br>
             <input type="file" id="cert" name="cert"
-                    accept=".pem,.crt,.cer,.der,
+                    accept=".pem,.crt,.cer,.der,.csr,.crl,.key,.pub,.p7b,.p7c,.p12,.pfx,
                             application/x-pem-file,
                             application/x-x509-ca-cert,
                             application/pem-certificate-chain,
                             application/pkix-cert,
+                            application/x-pkcs7-certificates,
+                            application/x-pkcs12,
                             application/octet-stream">
             <br>
+            <label for="password">PKCS#12 password:</label>
+            <input type="password" id="password" name="password" autocomplete="off">
+            <br>
             <button type="submit">Analyze Certificate</button>
+            <button type="submit" name="format" value="dot">Download issuer graph (DOT)</button>
         </form>
     </div>
 
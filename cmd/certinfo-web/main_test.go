@@ -1,10 +1,22 @@
 package main
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/tjarkko/go-demo/internal/pki"
 )
 
 // TestCertInfoGET tests the GET request handler
@@ -71,3 +83,192 @@ func TestTemplateExists(t *testing.T) {
 		t.Error("Template should not be nil")
 	}
 }
+
+// TestCertInfoUploadCSR tests that non-certificate PEM blocks are described
+func TestCertInfoUploadCSR(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "upload.example.com"},
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := newUploadRequest(t, "request.csr", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER}), nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(CertInfo).ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	body := rr.Body.String()
+	for _, s := range []string{"===== Certificate Request #1 =====", "CN=upload.example.com"} {
+		if !strings.Contains(body, s) {
+			t.Errorf("Expected response to contain '%s'", s)
+		}
+	}
+}
+
+// TestCertInfoUploadPKCS12 tests .pfx uploads with the password field
+func TestCertInfoUploadPKCS12(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "pfx.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pfx, err := pki.EncodePKCS12(key, []*x509.Certificate{cert}, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(CertInfo).ServeHTTP(rr, newUploadRequest(t, "bundle.pfx", pfx, map[string]string{"password": "secret"}))
+	body := rr.Body.String()
+	for _, s := range []string{"Input format:        PKCS#12", "===== Certificate #1 =====", "CN=pfx.example.com", "===== Private Key #2 ====="} {
+		if !strings.Contains(body, s) {
+			t.Errorf("Expected response to contain '%s'", s)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	http.HandlerFunc(CertInfo).ServeHTTP(rr, newUploadRequest(t, "bundle.pfx", pfx, map[string]string{"password": "wrong"}))
+	if body := rr.Body.String(); !strings.Contains(body, "password incorrect") {
+		t.Errorf("Expected a password error, got:\n%s", body)
+	}
+}
+
+// TestCertInfoUploadPKCS7 tests that DER .p7b uploads list their certificates
+func TestCertInfoUploadPKCS7(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "p7b.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p7b, err := pki.EncodePKCS7([]*x509.Certificate{cert})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(CertInfo).ServeHTTP(rr, newUploadRequest(t, "chain.p7b", p7b, nil))
+	body := rr.Body.String()
+	for _, s := range []string{"===== PKCS#7 #1 =====", "===== Certificate #1 =====", "CN=p7b.example.com"} {
+		if !strings.Contains(body, s) {
+			t.Errorf("Expected response to contain '%s'", s)
+		}
+	}
+}
+
+// TestCertInfoUploadChain tests that a bundle uploaded root first is shown
+// with its chain in leaf-to-root order
+func TestCertInfoUploadChain(t *testing.T) {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Upload Root"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "upload.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, rootTemplate, &leafKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER})...)
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(CertInfo).ServeHTTP(rr, newUploadRequest(t, "bundle.pem", bundle, nil))
+	body := rr.Body.String()
+	for _, s := range []string{"===== Chain =====", "complete (2 certificates)", "0: CN=upload.example.com", "1: CN=Upload Root (self-signed)", "Reordered:           true",
+		"===== Certificate Tree =====", "#1 CN=Upload Root [self-signed]", "└── #2 CN=upload.example.com"} {
+		if !strings.Contains(body, s) {
+			t.Errorf("Expected response to contain '%s'", s)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	http.HandlerFunc(CertInfo).ServeHTTP(rr, newUploadRequest(t, "bundle.pem", bundle, map[string]string{"format": "dot"}))
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/vnd.graphviz") {
+		t.Errorf("Expected a Graphviz content type, got %q", ct)
+	}
+	if body := rr.Body.String(); !strings.HasPrefix(body, "digraph certificates {") || !strings.Contains(body, "c1 -> c2;") {
+		t.Errorf("Expected a DOT graph with an edge from the root to the leaf, got:\n%s", body)
+	}
+}
+
+// newUploadRequest builds a multipart POST carrying data in the "cert" field
+// and any extra form fields
+func newUploadRequest(t *testing.T, filename string, data []byte, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := mw.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fw, err := mw.CreateFormFile("cert", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", "/", &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
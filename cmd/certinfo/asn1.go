@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+type ASN1Cmd struct {
+	FilePath string `arg:"" name:"file" help:"DER or PEM file to dump." type:"existingfile"`
+	Offset   int    `help:"Start parsing at this byte offset into the DER (of every PEM block)."`
+	Output   string `short:"o" enum:"text,json,yaml" default:"text" help:"Output format (text, json, yaml)."`
+}
+
+// asn1Entry is one element of the json/yaml output of ASN1Cmd: the ASN.1
+// tree of a PEM block or of the whole DER input.
+type asn1Entry struct {
+	Index int            `json:"index" yaml:"index"`
+	Type  string         `json:"type,omitempty" yaml:"type,omitempty"`
+	Nodes []pki.ASN1Node `json:"nodes" yaml:"nodes"`
+	Error string         `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func (a *ASN1Cmd) Run(ctx *Context) error {
+	data, err := os.ReadFile(a.FilePath)
+	if err != nil {
+		return err
+	}
+
+	// Dump PEM blocks one by one; anything else is taken as raw DER so
+	// that malformed input is shown as it is.
+	blocks := []pki.Block{{Bytes: data}}
+	if decoded, err := pki.DecodeAny(data, ""); err == nil && decoded.Format == "PEM" {
+		blocks = decoded.Blocks
+	}
+
+	var entries []asn1Entry
+	failed := false
+	for i, b := range blocks {
+		entry := asn1Entry{Index: i + 1, Type: b.Type, Nodes: []pki.ASN1Node{}}
+		if a.Offset < 0 || a.Offset >= len(b.Bytes) {
+			entry.Error = fmt.Sprintf("offset %d is outside the %d bytes of input", a.Offset, len(b.Bytes))
+		} else {
+			nodes, err := pki.ParseASN1(b.Bytes[a.Offset:])
+			for _, n := range nodes {
+				n.Offset += a.Offset
+				entry.Nodes = append(entry.Nodes, n)
+			}
+			var perr *pki.ASN1Error
+			if errors.As(err, &perr) {
+				perr.Offset += a.Offset
+			}
+			if err != nil {
+				entry.Error = err.Error()
+			}
+		}
+		failed = failed || entry.Error != ""
+		entries = append(entries, entry)
+	}
+
+	if a.Output != "text" {
+		if err := writeStructured(os.Stdout, a.Output, entries); err != nil {
+			return err
+		}
+	} else {
+		for _, e := range entries {
+			if e.Type != "" {
+				fmt.Printf("===== %s #%d =====\n", e.Type, e.Index)
+			}
+			fmt.Print(pki.ASN1Text(e.Nodes))
+			if e.Error != "" {
+				fmt.Printf("Error:               %s\n", e.Error)
+			}
+			if e.Type != "" {
+				fmt.Println()
+			}
+		}
+	}
+
+	if failed {
+		return errors.New("input is not well-formed DER")
+	}
+	return nil
+}
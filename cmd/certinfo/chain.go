@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+// chainExitIncomplete is the exit code of ChainCmd when the chain neither
+// reaches a self-signed root nor stops below a trusted one. Failures to run
+// at all exit with 1.
+const chainExitIncomplete = 2
+
+type ChainCmd struct {
+	Certs       []string      `arg:"" name:"cert-file" help:"Certificate files, in any order." type:"existingfile"`
+	Fetch       bool          `help:"Download missing intermediates from the AIA caIssuers URLs."`
+	Timeout     time.Duration `default:"10s" help:"Download timeout for --fetch."`
+	Roots       []string      `help:"Trusted root bundle (repeatable) the chain may stop below. Defaults to the system roots." type:"existingfile"`
+	ExcludeRoot bool          `help:"Leave the self-signed root out of the written chain, as servers should."`
+	Out         string        `help:"Write the ordered chain as PEM to this file." type:"path"`
+	Output      string        `short:"o" enum:"text,json,yaml,pem" default:"text" help:"Output format (text, json, yaml, or pem for the ordered chain itself)."`
+}
+
+func (c *ChainCmd) Run(ctx *Context) error {
+	var certs []*x509.Certificate
+	for _, path := range c.Certs {
+		more, err := loadCerts(path)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, more...)
+	}
+
+	opts := pki.ChainOptions{FetchAIA: c.Fetch, Timeout: c.Timeout}
+	for _, path := range c.Roots {
+		roots, err := loadCerts(path)
+		if err != nil {
+			return err
+		}
+		opts.Roots = append(opts.Roots, roots...)
+	}
+
+	res, err := pki.BuildChain(certs, opts)
+	if err != nil {
+		return err
+	}
+	chain := res.Chain
+	if c.ExcludeRoot && res.Complete && !res.RootOmitted && len(chain) > 1 {
+		chain = chain[:len(chain)-1]
+	}
+	if c.Out != "" {
+		if err := os.WriteFile(c.Out, pki.EncodeCertsPEM(chain...), 0o644); err != nil { // #nosec G306 -- certificates are public
+			return err
+		}
+	}
+
+	switch c.Output {
+	case "pem":
+		if _, err := os.Stdout.Write(pki.EncodeCertsPEM(chain...)); err != nil {
+			return err
+		}
+	case "text":
+		fmt.Print(res.Text())
+		if c.Out != "" {
+			fmt.Printf("Wrote %s (%d certificates)\n", c.Out, len(chain))
+		}
+	default:
+		if err := writeStructured(os.Stdout, c.Output, res); err != nil {
+			return err
+		}
+	}
+
+	if !res.Complete {
+		os.Exit(chainExitIncomplete)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+// checkHostExitMismatch is the exit code of CheckHostCmd when a host does
+// not match or is forbidden by a name constraint. Failures to run at all
+// exit with 1.
+const checkHostExitMismatch = 2
+
+type CheckHostCmd struct {
+	FilePath string   `arg:"" name:"cert-file" help:"Certificate, optionally followed by its chain." type:"existingfile"`
+	Hosts    []string `arg:"" name:"host" help:"Hostnames or IP addresses to check."`
+	Chain    []string `help:"Issuer certificates whose name constraints to apply (repeatable)." type:"existingfile"`
+	Output   string   `short:"o" enum:"text,json,yaml" default:"text" help:"Output format (text, json, yaml)."`
+}
+
+func (c *CheckHostCmd) Run(ctx *Context) error {
+	certs, err := loadCerts(c.FilePath)
+	if err != nil {
+		return err
+	}
+	chain := certs[1:]
+	for _, path := range c.Chain {
+		more, err := loadCerts(path)
+		if err != nil {
+			return err
+		}
+		chain = append(chain, more...)
+	}
+
+	res := pki.CheckHosts(certs[0], chain, c.Hosts)
+	if c.Output == "text" {
+		fmt.Print(res.Text())
+	} else if err := writeStructured(os.Stdout, c.Output, res); err != nil {
+		return err
+	}
+
+	if !res.OK() {
+		os.Exit(checkHostExitMismatch)
+	}
+	return nil
+}
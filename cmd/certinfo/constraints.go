@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+// constraintsExitViolation is the exit code of ConstraintsCmd when a SAN
+// violates a name constraint. Failures to run at all exit with 1.
+const constraintsExitViolation = 2
+
+type ConstraintsCmd struct {
+	FilePath string   `arg:"" name:"cert-file" help:"Certificate, optionally followed by its chain." type:"existingfile"`
+	Chain    []string `help:"Issuer certificates whose name constraints to apply (repeatable)." type:"existingfile"`
+	Output   string   `short:"o" enum:"text,json,yaml" default:"text" help:"Output format (text, json, yaml)."`
+}
+
+func (c *ConstraintsCmd) Run(ctx *Context) error {
+	certs, err := loadCerts(c.FilePath)
+	if err != nil {
+		return err
+	}
+	chain := certs[1:]
+	for _, path := range c.Chain {
+		more, err := loadCerts(path)
+		if err != nil {
+			return err
+		}
+		chain = append(chain, more...)
+	}
+
+	res := pki.EvaluateNameConstraints(certs[0], chain)
+	if c.Output == "text" {
+		fmt.Print(res.Text())
+	} else if err := writeStructured(os.Stdout, c.Output, res); err != nil {
+		return err
+	}
+
+	if !res.OK() {
+		os.Exit(constraintsExitViolation)
+	}
+	return nil
+}
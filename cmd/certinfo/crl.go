@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+type CRLCmd struct {
+	FilePath string `arg:"" name:"crl-file" help:"CRL (PEM or DER)." type:"existingfile"`
+	Issuer   string `help:"Issuer certificate to verify the CRL signature against." type:"existingfile"`
+	Cert     string `help:"Certificate to look up in the CRL." type:"existingfile"`
+	Output   string `short:"o" enum:"text,json,yaml" default:"text" help:"Output format (text, json, yaml)."`
+}
+
+// crlReport is the json/yaml output of CRLCmd.
+type crlReport struct {
+	CRL             *pki.CRLInfo      `json:"crl" yaml:"crl"`
+	SignatureValid  *bool             `json:"signature_valid,omitempty" yaml:"signature_valid,omitempty"`
+	SignatureError  string            `json:"signature_error,omitempty" yaml:"signature_error,omitempty"`
+	Certificate     string            `json:"certificate,omitempty" yaml:"certificate,omitempty"`
+	Revoked         *bool             `json:"revoked,omitempty" yaml:"revoked,omitempty"`
+	RevocationEntry *pki.RevokedEntry `json:"revocation_entry,omitempty" yaml:"revocation_entry,omitempty"`
+}
+
+func (c *CRLCmd) Run(ctx *Context) error {
+	data, err := os.ReadFile(c.FilePath)
+	if err != nil {
+		return err
+	}
+	crl, err := pki.ParseCRL(data)
+	if err != nil {
+		return fmt.Errorf("not a CRL: %w", err)
+	}
+
+	report := crlReport{CRL: pki.GetCRLInfo(crl)}
+	if c.Issuer != "" {
+		issuers, err := loadCerts(c.Issuer)
+		if err != nil {
+			return err
+		}
+		valid := true
+		if err := pki.VerifyCRL(crl, issuers[0]); err != nil {
+			valid = false
+			report.SignatureError = err.Error()
+		}
+		report.SignatureValid = &valid
+	}
+	if c.Cert != "" {
+		certs, err := loadCerts(c.Cert)
+		if err != nil {
+			return err
+		}
+		entry, err := pki.CheckRevocation(crl, certs[0])
+		if err != nil {
+			return err
+		}
+		revoked := entry != nil
+		report.Certificate = pki.FormatName(certs[0].Subject)
+		report.Revoked = &revoked
+		report.RevocationEntry = entry
+	}
+
+	if c.Output != "text" {
+		if err := writeStructured(os.Stdout, c.Output, report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Print(report.CRL.Text())
+		if report.SignatureValid != nil {
+			if *report.SignatureValid {
+				fmt.Printf("Signature:           valid\n")
+			} else {
+				fmt.Printf("Signature:           INVALID (%s)\n", report.SignatureError)
+			}
+		}
+		if report.Revoked != nil {
+			if e := report.RevocationEntry; e != nil {
+				reason := ""
+				if e.Reason != "" {
+					reason = " (" + e.Reason + ")"
+				}
+				fmt.Printf("Certificate:         %s: REVOKED at %s%s\n", report.Certificate, e.RevokedAt.Format(time.RFC3339), reason)
+			} else {
+				fmt.Printf("Certificate:         %s: not revoked\n", report.Certificate)
+			}
+		}
+	}
+
+	if report.SignatureValid != nil && !*report.SignatureValid {
+		return errors.New("CRL signature is invalid")
+	}
+	if report.Revoked != nil && *report.Revoked {
+		return errors.New("certificate is revoked")
+	}
+	return nil
+}
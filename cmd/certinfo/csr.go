@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+type CSRCmd struct {
+	FilePath string `arg:"" name:"csr-file" help:"PKCS#10 request (PEM or DER)." type:"existingfile"`
+	Output   string `short:"o" enum:"text,json,yaml" default:"text" help:"Output format (text, json, yaml)."`
+}
+
+func (c *CSRCmd) Run(ctx *Context) error {
+	data, err := os.ReadFile(c.FilePath)
+	if err != nil {
+		return err
+	}
+
+	var ders [][]byte
+	for _, b := range pki.ReadBlocks(data) {
+		if b.Kind == pki.BlockCSR {
+			ders = append(ders, b.Bytes)
+		}
+	}
+	if len(ders) == 0 {
+		// Maybe DER
+		ders = [][]byte{data}
+	}
+
+	var infos []*pki.CSRInfo
+	for i, der := range ders {
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil {
+			return fmt.Errorf("#%d: not a certificate request: %w", i+1, err)
+		}
+		infos = append(infos, pki.GetCSRInfo(csr))
+	}
+
+	if c.Output != "text" {
+		if err := writeStructured(os.Stdout, c.Output, infos); err != nil {
+			return err
+		}
+	} else {
+		for i, info := range infos {
+			fmt.Printf("===== Certificate Request #%d =====\n", i+1)
+			fmt.Print(info.Text())
+			fmt.Println()
+		}
+	}
+
+	for _, info := range infos {
+		if !info.SignatureValid {
+			return errors.New("certificate request signature is invalid")
+		}
+	}
+	return nil
+}
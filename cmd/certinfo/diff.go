@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+// diffExitChanged is the exit code of DiffCmd when the certificates differ
+// beyond a plain renewal. Failures to run at all exit with 1.
+const diffExitChanged = 2
+
+const (
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiDim   = "\x1b[2m"
+	ansiReset = "\x1b[0m"
+)
+
+type DiffCmd struct {
+	Old    string `arg:"" name:"old-cert" help:"Certificate before the change (the first one in the file is used)." type:"existingfile"`
+	New    string `arg:"" name:"new-cert" help:"Certificate after the change (the first one in the file is used)." type:"existingfile"`
+	Color  string `enum:"auto,always,never" default:"auto" help:"Color the text output (auto, always, never)."`
+	Output string `short:"o" enum:"text,json,yaml" default:"text" help:"Output format (text, json, yaml)."`
+}
+
+// diffReport is the json/yaml output of DiffCmd.
+type diffReport struct {
+	Old         string       `json:"old" yaml:"old"`
+	New         string       `json:"new" yaml:"new"`
+	Significant bool         `json:"significant" yaml:"significant"`
+	Changes     []pki.Change `json:"changes" yaml:"changes"`
+}
+
+func (d *DiffCmd) Run(ctx *Context) error {
+	oldCerts, err := loadCerts(d.Old)
+	if err != nil {
+		return err
+	}
+	newCerts, err := loadCerts(d.New)
+	if err != nil {
+		return err
+	}
+
+	diff := pki.DiffCertInfo(pki.GetCertInfo(oldCerts[0]), pki.GetCertInfo(newCerts[0]))
+
+	if d.Output != "text" {
+		report := diffReport{Old: d.Old, New: d.New, Significant: diff.Significant(), Changes: diff.Changes}
+		if err := writeStructured(os.Stdout, d.Output, report); err != nil {
+			return err
+		}
+	} else {
+		printDiff(os.Stdout, diff, useColor(d.Color))
+	}
+
+	if diff.Significant() {
+		os.Exit(diffExitChanged)
+	}
+	return nil
+}
+
+// useColor resolves the --color mode; auto colors only terminals and honours
+// NO_COLOR.
+func useColor(mode string) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func printDiff(w io.Writer, diff *pki.CertDiff, color bool) {
+	paint := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return code + s + ansiReset
+	}
+
+	if len(diff.Changes) == 0 {
+		fmt.Fprintln(w, "Certificates are identical.")
+		return
+	}
+
+	for _, c := range diff.Changes {
+		label := fmt.Sprintf("%-21s", c.Field+":")
+		if !c.Significant {
+			label = paint(ansiDim, label)
+		}
+		var lines []string
+		if c.Added == nil && c.Removed == nil {
+			lines = append(lines, paint(ansiRed, "- "+orNone(c.Old)), paint(ansiGreen, "+ "+orNone(c.New)))
+		} else {
+			for _, s := range c.Removed {
+				lines = append(lines, paint(ansiRed, "- "+s))
+			}
+			for _, s := range c.Added {
+				lines = append(lines, paint(ansiGreen, "+ "+s))
+			}
+		}
+		fmt.Fprintf(w, "%s%s\n", label, lines[0])
+		for _, l := range lines[1:] {
+			fmt.Fprintf(w, "%s%s\n", strings.Repeat(" ", 21), l)
+		}
+	}
+
+	fmt.Fprintln(w)
+	if diff.Significant() {
+		fmt.Fprintln(w, "Result:              certificates differ")
+	} else {
+		fmt.Fprintln(w, "Result:              renewal only (serial, validity, key IDs, fingerprint)")
+	}
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
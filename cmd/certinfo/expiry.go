@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+// Exit codes of ExpiryCmd by the most urgent status found. Failures to run
+// at all exit with 1.
+const (
+	expiryExitWarning  = 2
+	expiryExitCritical = 3
+)
+
+type ExpiryCmd struct {
+	Paths       []string      `arg:"" optional:"" name:"path" help:"Certificate files or directories to scan recursively." type:"existingpath"`
+	Target      []string      `help:"TLS endpoint (host:port; the port defaults to 443) to check (repeatable)."`
+	TargetsFile string        `help:"File with one host:port per line; blank lines and # comments are ignored." type:"existingfile"`
+	Warning     int           `default:"30" help:"Days before expiry to report a warning."`
+	Critical    int           `default:"7" help:"Days before expiry to report critical."`
+	Timeout     time.Duration `default:"10s" help:"Handshake timeout per endpoint."`
+	Output      string        `short:"o" enum:"table,json,yaml,prometheus" default:"table" help:"Output format (table, json, yaml, prometheus)."`
+}
+
+func (e *ExpiryCmd) Run(ctx *Context) error {
+	targets := e.Target
+	if e.TargetsFile != "" {
+		more, err := readLines(e.TargetsFile)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, more...)
+	}
+	if len(e.Paths) == 0 && len(targets) == 0 {
+		return errors.New("nothing to check: give a path, --target or --targets-file")
+	}
+
+	now := time.Now()
+	th := pki.ExpiryThresholds{Warning: e.Warning, Critical: e.Critical}
+	entries := []pki.ExpiryEntry{}
+	for _, path := range e.Paths {
+		found, err := pki.ScanExpiryDir(path, now, th)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, found...)
+	}
+	for _, addr := range targets {
+		info, err := pki.FetchTLS(defaultPort(addr), pki.FetchOptions{Timeout: e.Timeout})
+		if err != nil {
+			entries = append(entries, pki.ExpiryEntry{Source: addr, Status: pki.ExpiryError, Error: err.Error()})
+			continue
+		}
+		entries = append(entries, pki.CheckExpiry(addr, info.Certificates, now, th)...)
+	}
+	pki.SortExpiry(entries)
+
+	var err error
+	switch e.Output {
+	case "table":
+		err = printExpiryTable(os.Stdout, entries)
+	case "prometheus":
+		err = writeExpiryPrometheus(os.Stdout, entries, now)
+	default:
+		err = writeStructured(os.Stdout, e.Output, entries)
+	}
+	if err != nil {
+		return err
+	}
+
+	code := 0
+	for _, entry := range entries {
+		switch entry.Status {
+		case pki.ExpiryError, pki.ExpiryExpired, pki.ExpiryCritical:
+			code = expiryExitCritical
+		case pki.ExpiryWarning:
+			if code == 0 {
+				code = expiryExitWarning
+			}
+		}
+	}
+	if code != 0 {
+		os.Exit(code)
+	}
+	return nil
+}
+
+// readLines reads the non-blank lines of path that are not # comments,
+// such as host:port targets or pins.
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return out, sc.Err()
+}
+
+func printExpiryTable(w io.Writer, entries []pki.ExpiryEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tDAYS\tNOT AFTER\tSUBJECT\tSOURCE")
+	for _, e := range entries {
+		source := e.Source
+		if e.Index > 0 {
+			source = fmt.Sprintf("%s #%d", e.Source, e.Index)
+		}
+		if e.Status == pki.ExpiryError {
+			fmt.Fprintf(tw, "%s\t-\t-\t%s\t%s\n", e.Status, e.Error, source)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", e.Status, e.DaysRemaining, e.NotAfter.Format(time.RFC3339), e.Subject, source)
+	}
+	return tw.Flush()
+}
+
+// writeExpiryPrometheus writes entries in the Prometheus text exposition
+// format, for node_exporter's textfile collector.
+func writeExpiryPrometheus(w io.Writer, entries []pki.ExpiryEntry, now time.Time) error {
+	var buf strings.Builder
+	metric := func(name, help string, each func(e pki.ExpiryEntry) (string, bool)) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(&buf, "# TYPE %s gauge\n", name)
+		for _, e := range entries {
+			if v, ok := each(e); ok {
+				fmt.Fprintf(&buf, "%s{%s} %s\n", name, expiryLabels(e), v)
+			}
+		}
+	}
+
+	metric("certinfo_cert_not_after_timestamp_seconds", "Certificate expiry time as a Unix timestamp.",
+		func(e pki.ExpiryEntry) (string, bool) {
+			if e.NotAfter == nil {
+				return "", false
+			}
+			return fmt.Sprint(e.NotAfter.Unix()), true
+		})
+	metric("certinfo_cert_days_remaining", "Whole days until the certificate expires; negative once expired.",
+		func(e pki.ExpiryEntry) (string, bool) {
+			return fmt.Sprint(e.DaysRemaining), e.Status != pki.ExpiryError
+		})
+	metric("certinfo_cert_check_error", "1 if the source could not be read or parsed.",
+		func(e pki.ExpiryEntry) (string, bool) {
+			return "1", e.Status == pki.ExpiryError
+		})
+	fmt.Fprintf(&buf, "# HELP certinfo_expiry_last_run_timestamp_seconds Time of the last expiry check.\n")
+	fmt.Fprintf(&buf, "# TYPE certinfo_expiry_last_run_timestamp_seconds gauge\n")
+	fmt.Fprintf(&buf, "certinfo_expiry_last_run_timestamp_seconds %d\n", now.Unix())
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// expiryLabels labels a sample by source and index, which together are
+// unique per entry; an error that concerns a whole source has index 0.
+func expiryLabels(e pki.ExpiryEntry) string {
+	labels := []string{
+		fmt.Sprintf(`source="%s"`, promEscape(e.Source)),
+		fmt.Sprintf(`index="%d"`, e.Index),
+	}
+	if e.Status != pki.ExpiryError {
+		labels = append(labels,
+			fmt.Sprintf(`subject="%s"`, promEscape(e.Subject)),
+			fmt.Sprintf(`serial="%s"`, promEscape(e.Serial)))
+	}
+	return strings.Join(labels, ",")
+}
+
+// promEscape escapes a Prometheus label value.
+func promEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+// TestWriteExpiryPrometheusErrors tests that every bad block of a file gets
+// its own series
+func TestWriteExpiryPrometheusErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := "-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n"
+	if err := os.WriteFile(filepath.Join(dir, "broken.pem"), []byte(bad+bad), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	entries, err := pki.ScanExpiryDir(dir, now, pki.ExpiryThresholds{Warning: 30, Critical: 7})
+	if err != nil {
+		t.Fatalf("ScanExpiryDir failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 error entries, got %+v", entries)
+	}
+
+	var buf strings.Builder
+	if err := writeExpiryPrometheus(&buf, entries, now); err != nil {
+		t.Fatalf("writeExpiryPrometheus failed: %v", err)
+	}
+	seen := map[string]bool{}
+	var errorSamples int
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		series, _, _ := strings.Cut(line, "} ")
+		if seen[series] {
+			t.Errorf("Expected unique series, got %s twice", series)
+		}
+		seen[series] = true
+		if strings.HasPrefix(line, "certinfo_cert_check_error{") {
+			errorSamples++
+		}
+	}
+	if errorSamples != 2 {
+		t.Errorf("Expected 2 certinfo_cert_check_error samples, got %d:\n%s", errorSamples, buf.String())
+	}
+	if !strings.Contains(buf.String(), `index="2"} 1`) {
+		t.Errorf("Expected the second block labelled index=\"2\", got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+type FetchCmd struct {
+	Address string        `arg:"" name:"host:port" help:"TLS endpoint; the port defaults to 443."`
+	SNI     string        `help:"Server name to send (defaults to the host)."`
+	ALPN    []string      `name:"alpn" help:"ALPN protocols to offer (repeatable)."`
+	Cert    string        `help:"Client certificate for mutual TLS." type:"existingfile"`
+	Key     string        `help:"Client private key for mutual TLS." type:"existingfile"`
+	Roots   []string      `help:"Trusted root bundle (repeatable). Defaults to the system roots." type:"existingfile"`
+	Timeout time.Duration `default:"10s" help:"Dial and handshake timeout."`
+}
+
+func (f *FetchCmd) Run(ctx *Context) error {
+	addr := defaultPort(f.Address)
+
+	opts := pki.FetchOptions{ServerName: f.SNI, ALPN: f.ALPN, Timeout: f.Timeout}
+	if f.Cert != "" || f.Key != "" {
+		if f.Cert == "" || f.Key == "" {
+			return errors.New("--cert and --key must be given together")
+		}
+		pair, err := tls.LoadX509KeyPair(f.Cert, f.Key)
+		if err != nil {
+			return err
+		}
+		opts.ClientCert = &pair
+	}
+	for _, path := range f.Roots {
+		roots, err := loadCerts(path)
+		if err != nil {
+			return err
+		}
+		opts.Roots = append(opts.Roots, roots...)
+	}
+
+	info, err := pki.FetchTLS(addr, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Address:             %s\n", info.Address)
+	fmt.Printf("Server Name:         %s\n", info.ServerName)
+	fmt.Printf("Protocol:            %s\n", info.Version)
+	fmt.Printf("Cipher Suite:        %s\n", info.CipherSuite)
+	if info.ALPN != "" {
+		fmt.Printf("ALPN:                %s\n", info.ALPN)
+	}
+	if len(info.OCSPResponse) > 0 {
+		fmt.Printf("OCSP Stapled:        yes (%d bytes)\n", len(info.OCSPResponse))
+		if info.OCSPErr != nil {
+			fmt.Printf("OCSP Status:         INVALID (%v)\n", info.OCSPErr)
+		} else {
+			fmt.Printf("OCSP Status:         %s\n", info.OCSP.Status)
+		}
+	} else {
+		fmt.Printf("OCSP Stapled:        no\n")
+	}
+	if info.Verify.OK() {
+		fmt.Printf("Verification:        OK\n")
+	} else if info.Verify.Err != nil {
+		fmt.Printf("Verification:        FAILED: %v\n", info.Verify.Err)
+	} else {
+		fmt.Printf("Verification:        FAILED: %v\n", info.Verify.HostErr)
+	}
+	fmt.Println()
+
+	for i, cert := range info.Certificates {
+		fmt.Printf("===== Certificate #%d =====\n", i+1)
+		pki.PrintCertInfo(cert)
+		fmt.Println()
+	}
+	return nil
+}
+
+// defaultPort adds the HTTPS port to a TLS endpoint given without one. An
+// IPv6 address may come bare or in brackets.
+func defaultPort(addr string) string {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		host := strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+		return net.JoinHostPort(host, "443")
+	}
+	return addr
+}
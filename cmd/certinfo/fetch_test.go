@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+// TestDefaultPort tests adding the default port to TLS endpoints
+func TestDefaultPort(t *testing.T) {
+	testCases := []struct {
+		addr     string
+		expected string
+	}{
+		{"example.com", "example.com:443"},
+		{"example.com:8443", "example.com:8443"},
+		{"192.0.2.1", "192.0.2.1:443"},
+		{"::1", "[::1]:443"},
+		{"[::1]", "[::1]:443"},
+		{"[::1]:8443", "[::1]:8443"},
+	}
+
+	for _, tc := range testCases {
+		if got := defaultPort(tc.addr); got != tc.expected {
+			t.Errorf("defaultPort(%q) = %q, expected %q", tc.addr, got, tc.expected)
+		}
+	}
+}
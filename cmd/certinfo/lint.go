@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+	"github.com/tjarkko/go-demo/internal/pki/lint"
+)
+
+// Exit codes of LintCmd by the highest severity found. Failures to run at
+// all exit with 1.
+const (
+	lintExitWarning = 2
+	lintExitError   = 3
+)
+
+type LintCmd struct {
+	FilePath    string `arg:"" name:"cert-file" help:"Certificate or PEM bundle to lint." type:"existingfile"`
+	MinSeverity string `enum:"info,warning,error" default:"info" help:"Hide findings below this severity (info, warning, error)."`
+	Output      string `short:"o" enum:"text,json,yaml" default:"text" help:"Output format (text, json, yaml)."`
+}
+
+// lintReport is the json/yaml output of LintCmd for one certificate.
+type lintReport struct {
+	Index    int            `json:"index" yaml:"index"`
+	Subject  string         `json:"subject" yaml:"subject"`
+	Findings []lint.Finding `json:"findings" yaml:"findings"`
+}
+
+func (l *LintCmd) Run(ctx *Context) error {
+	certs, err := loadCerts(l.FilePath)
+	if err != nil {
+		return err
+	}
+	minSev, err := lint.ParseSeverity(l.MinSeverity)
+	if err != nil {
+		return err
+	}
+
+	var reports []lintReport
+	var all []lint.Finding
+	for i, c := range certs {
+		report := lintReport{Index: i + 1, Subject: pki.FormatName(c.Subject), Findings: []lint.Finding{}}
+		for _, f := range lint.Lint(c) {
+			if f.Severity >= minSev {
+				report.Findings = append(report.Findings, f)
+			}
+		}
+		all = append(all, report.Findings...)
+		reports = append(reports, report)
+	}
+
+	if l.Output != "text" {
+		if err := writeStructured(os.Stdout, l.Output, reports); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range reports {
+			fmt.Printf("===== Certificate #%d: %s =====\n", r.Index, r.Subject)
+			if len(r.Findings) == 0 {
+				fmt.Println("No findings.")
+			}
+			for _, f := range r.Findings {
+				fmt.Printf("%-8s %-38s %s\n", f.Severity, f.RuleID, f.Message)
+			}
+			fmt.Println()
+		}
+	}
+
+	if max, ok := lint.MaxSeverity(all); ok {
+		switch max {
+		case lint.Error:
+			os.Exit(lintExitError)
+		case lint.Warning:
+			os.Exit(lintExitWarning)
+		}
+	}
+	return nil
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/alecthomas/kong"
 	"github.com/tjarkko/go-demo/internal/pki"
+	"gopkg.in/yaml.v3"
 )
 
 type Context struct {
@@ -14,6 +19,33 @@ type Context struct {
 
 type PrintCmd struct {
 	FilePath string `arg:"" name:"cert-file" help:"Cert file." type:"existingfile"`
+	Output   string `short:"o" enum:"text,json,yaml" default:"text" help:"Output format (text, json, yaml)."`
+	passwordFlags
+
+	SCT       bool   `name:"sct" help:"Show embedded Certificate Transparency SCTs and verify their signatures."`
+	CTLogList string `name:"ct-log-list" help:"CT log list JSON (v3 schema) used to identify logs and verify SCTs; implies --sct." type:"existingfile"`
+	Issuer    string `help:"Issuer certificate for SCT verification (defaults to the issuer found in cert-file)." type:"existingfile"`
+
+	ASN1 bool `name:"asn1" help:"Dump the ASN.1 structure of objects that fail to parse."`
+
+	Tree bool `help:"After the objects, show which certificate issued which."`
+
+	Fingerprints []string `name:"fingerprints" sep:"," enum:"sha1,sha256,sha384,sha512,spki,ski,all" help:"Fingerprints to show in the text output: sha1, sha256, sha384, sha512, spki (RFC 7469 pin), ski (computed key IDs) or all. Defaults to sha256."`
+}
+
+// certEntry is one element of the json/yaml output of PrintCmd: the parsed
+// certificate, the contents of a PKCS#7 bundle, a text summary of any other
+// object, or the reason the block could not be parsed.
+type certEntry struct {
+	Index       int            `json:"index" yaml:"index"`
+	Kind        pki.BlockKind  `json:"kind" yaml:"kind"`
+	Format      string         `json:"format" yaml:"format"`
+	Certificate *pki.CertInfo  `json:"certificate,omitempty" yaml:"certificate,omitempty"`
+	PKCS7       *pki.PKCS7Info `json:"pkcs7,omitempty" yaml:"pkcs7,omitempty"`
+	SCTs        []pki.SCTInfo  `json:"scts,omitempty" yaml:"scts,omitempty"`
+	ASN1        []pki.ASN1Node `json:"asn1,omitempty" yaml:"asn1,omitempty"`
+	Summary     string         `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Error       string         `json:"error,omitempty" yaml:"error,omitempty"`
 }
 
 func fail(err error) {
@@ -27,34 +59,233 @@ func (p *PrintCmd) Run(ctx *Context) error {
 		fail(err)
 	}
 
-	blocks := pki.ReadPEMBlocks(data)
-	if len(blocks) == 0 {
-		// Maybe DER
-		blocks = [][]byte{data}
+	decoded, err := decodeInput(data, p.passwordFlags)
+	if err != nil {
+		return err
+	}
+	blocks := decoded.Blocks
+
+	sct, err := p.sctChecker(blocks)
+	if err != nil {
+		return err
 	}
 
+	if p.Output != "text" {
+		var entries []certEntry
+		for i, b := range blocks {
+			entry := certEntry{Index: i + 1, Kind: b.Kind, Format: decoded.Format}
+			if b.Kind == pki.BlockCertificate {
+				if cert, err := pki.TryParseCert(b.Bytes); err != nil {
+					entry.Error = b.FormatError(err)
+				} else {
+					entry.Certificate = pki.GetCertInfo(cert)
+					if sct != nil {
+						if entry.SCTs, err = sct(cert); err != nil {
+							entry.Error = err.Error()
+						}
+					}
+				}
+			} else if b.Kind == pki.BlockPKCS7 {
+				if p7, err := pki.ParsePKCS7(b.Bytes); err != nil {
+					entry.Error = b.FormatError(err)
+				} else {
+					entry.PKCS7 = pki.GetPKCS7Info(p7)
+				}
+			} else if summary, err := b.Summary(); err != nil {
+				entry.Error = b.FormatError(err)
+			} else {
+				entry.Summary = summary
+			}
+			if p.ASN1 && entry.Error != "" {
+				entry.ASN1, _ = pki.ParseASN1(b.Bytes)
+			}
+			entries = append(entries, entry)
+		}
+		return writeStructured(os.Stdout, p.Output, entries)
+	}
+
+	fmt.Printf("Input format:        %s\n\n", decoded.Format)
 	for i, b := range blocks {
-		cert, err := pki.TryParseCert(b)
+		summary, err := b.Summary()
 		if err != nil {
-			fmt.Printf("#%d: not a certificate: %v\n\n", i+1, err)
+			fmt.Printf("#%d: %s\n\n", i+1, b.FormatError(err))
+			if p.ASN1 {
+				nodes, err := pki.ParseASN1(b.Bytes)
+				fmt.Print(pki.ASN1Text(nodes))
+				if err != nil {
+					fmt.Printf("Error:               %v\n", err)
+				}
+				fmt.Println()
+			}
 			continue
 		}
-		fmt.Printf("===== Certificate #%d =====\n", i+1)
-		pki.PrintCertInfo(cert)
+		if b.Kind == pki.BlockCertificate && len(p.Fingerprints) > 0 {
+			cert, _ := pki.TryParseCert(b.Bytes)
+			summary = pki.GetCertInfo(cert).TextWithFingerprints(p.fingerprintAlgorithms())
+		}
+		fmt.Printf("===== %s #%d =====\n", b.Kind, i+1)
+		fmt.Print(summary)
+		if sct != nil && b.Kind == pki.BlockCertificate {
+			cert, _ := pki.TryParseCert(b.Bytes)
+			if scts, err := sct(cert); err != nil {
+				fmt.Printf("SCTs:                %v\n", err)
+			} else {
+				fmt.Print(pki.SCTText(scts))
+			}
+		}
 		fmt.Println()
 	}
 
+	if p.Tree {
+		// Blocks that failed to parse were reported above; the tree
+		// shows the certificates that did.
+		certs, err := decoded.Certificates()
+		if len(certs) == 0 {
+			return err
+		}
+		fmt.Println("===== Certificate Tree =====")
+		fmt.Print(pki.BuildCertTree(certs).Text())
+	}
+
 	return nil
 }
 
+// fingerprintAlgorithms expands --fingerprints, where "all" selects every
+// algorithm.
+func (p *PrintCmd) fingerprintAlgorithms() []string {
+	for _, a := range p.Fingerprints {
+		if a == "all" {
+			return pki.FingerprintAlgorithms
+		}
+	}
+	return p.Fingerprints
+}
+
+// sctChecker returns the function that checks a certificate's SCTs, or nil
+// when neither --sct nor --ct-log-list was given. Issuers are looked up
+// among the certificates in the input and in --issuer.
+func (p *PrintCmd) sctChecker(blocks []pki.Block) (func(*x509.Certificate) ([]pki.SCTInfo, error), error) {
+	if !p.SCT && p.CTLogList == "" {
+		return nil, nil
+	}
+
+	var logs *pki.CTLogList
+	if p.CTLogList != "" {
+		var err error
+		if logs, err = pki.LoadCTLogList(p.CTLogList); err != nil {
+			return nil, err
+		}
+	}
+
+	var candidates []*x509.Certificate
+	for _, b := range blocks {
+		switch b.Kind {
+		case pki.BlockCertificate:
+			if cert, err := pki.TryParseCert(b.Bytes); err == nil {
+				candidates = append(candidates, cert)
+			}
+		case pki.BlockPKCS7:
+			if p7, err := pki.ParsePKCS7(b.Bytes); err == nil {
+				candidates = append(candidates, p7.Certificates...)
+			}
+		}
+	}
+	if p.Issuer != "" {
+		issuers, err := loadCerts(p.Issuer)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, issuers...)
+	}
+
+	return func(cert *x509.Certificate) ([]pki.SCTInfo, error) {
+		return pki.CheckSCTs(cert, pki.FindIssuer(cert, candidates), logs)
+	}, nil
+}
+
+// decodeInput runs pki.DecodeAny on data, asking for a password if it
+// turns out to be a protected PKCS#12 bundle.
+func decodeInput(data []byte, f passwordFlags) (*pki.Decoded, error) {
+	var pw string
+	if f.PasswordFile != "" {
+		var err error
+		if pw, err = f.password(false); err != nil {
+			return nil, err
+		}
+	}
+	decoded, err := pki.DecodeAny(data, pw)
+	if errors.Is(err, pki.ErrPasswordRequired) && f.PasswordFile == "" {
+		if pw, err = f.password(false); err != nil {
+			return nil, err
+		}
+		decoded, err = pki.DecodeAny(data, pw)
+	}
+	return decoded, err
+}
+
+// loadCerts reads a PEM bundle or DER certificate from path.
+func loadCerts(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := pki.ParseCertificates(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return certs, nil
+}
+
+// writeStructured encodes v as indented JSON or as YAML.
+func writeStructured(w io.Writer, format string, v any) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
 var cli struct {
-	Debug bool `help:"Enable debug mode."`
+	Debug   bool   `help:"Enable debug mode."`
+	OIDFile string `name:"oid-file" env:"CERTINFO_OID_FILE" help:"File of \"<oid> <name>\" lines naming OIDs in addition to the built-in registry." type:"existingfile"`
+
+	Print  PrintCmd  `cmd:"" help:"Print cert."`
+	Verify VerifyCmd `cmd:"" help:"Verify a certificate chain."`
+	Fetch  FetchCmd  `cmd:"" help:"Inspect the certificate chain presented by a TLS endpoint."`
+	CSR    CSRCmd    `cmd:"" name:"csr" help:"Inspect and validate a certificate request."`
+	CRL    CRLCmd    `cmd:"" name:"crl" help:"Inspect a CRL and check certificates against it."`
+	OCSP   OCSPCmd   `cmd:"" name:"ocsp" help:"Check certificate status with OCSP."`
+	CA     CACmd     `cmd:"" name:"ca" help:"Run a local certificate authority."`
+	Lint   LintCmd   `cmd:"" help:"Check certificates against CA/B Forum and RFC 5280 rules."`
+	Diff   DiffCmd   `cmd:"" help:"Compare two certificates field by field."`
+	Expiry ExpiryCmd `cmd:"" help:"Report days until expiry for certificate files and TLS endpoints."`
+	PKCS12 PKCS12Cmd `cmd:"" name:"pkcs12" help:"Read and write PKCS#12 (.p12, .pfx) bundles."`
+	PKCS7  PKCS7Cmd  `cmd:"" name:"pkcs7" help:"Write PKCS#7 (.p7b) certificate bundles."`
+	ASN1   ASN1Cmd   `cmd:"" name:"asn1" help:"Dump the ASN.1 structure of DER or PEM input."`
 
-	Print PrintCmd `cmd:"" help:"Print cert."`
+	MatchPin    MatchPinCmd    `cmd:"" name:"match-pin" help:"Check a certificate chain against a set of SPKI pins."`
+	Match       MatchCmd       `cmd:"" help:"Check that a private key belongs to a certificate or CSR, or pair up the keys in a directory."`
+	Chain       ChainCmd       `cmd:"" help:"Order certificates leaf to root, flag missing and extraneous ones, and write the chain as PEM."`
+	Tree        TreeCmd        `cmd:"" help:"Show which certificate issued which, as a tree or Graphviz graph."`
+	CheckHost   CheckHostCmd   `cmd:"" name:"check-host" help:"Check whether hostnames or IP addresses match a certificate's SANs."`
+	Constraints ConstraintsCmd `cmd:"" help:"Check a certificate's SANs against the name constraints of its issuers."`
 }
 
 func main() {
 	ctx := kong.Parse(&cli)
+	if cli.OIDFile != "" {
+		ctx.FatalIfErrorf(pki.LoadOIDFile(cli.OIDFile))
+	}
 	// Call the Run() method of the selected parsed command.
 	err := ctx.Run(&Context{Debug: cli.Debug})
 	ctx.FatalIfErrorf(err)
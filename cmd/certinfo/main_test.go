@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestPrintJSON tests the schema of certinfo print -o json
+func TestPrintJSON(t *testing.T) {
+	out := capturePrint(t, &PrintCmd{FilePath: "../../examples/server.crt", Output: "json"})
+	var entries []map[string]any
+	if err := json.Unmarshal(out, &entries); err != nil {
+		t.Fatalf("Failed to decode JSON output: %v\n%s", err, out)
+	}
+	checkPrintEntries(t, entries)
+}
+
+// TestPrintYAML tests the schema of certinfo print -o yaml
+func TestPrintYAML(t *testing.T) {
+	out := capturePrint(t, &PrintCmd{FilePath: "../../examples/server.crt", Output: "yaml"})
+	var entries []map[string]any
+	if err := yaml.Unmarshal(out, &entries); err != nil {
+		t.Fatalf("Failed to decode YAML output: %v\n%s", err, out)
+	}
+	checkPrintEntries(t, entries)
+}
+
+// TestPrintTreePartial tests that --tree still renders when one block of
+// the input does not parse
+func TestPrintTreePartial(t *testing.T) {
+	server, err := os.ReadFile("../../examples/server.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := os.ReadFile("../../examples/root.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := "-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n"
+	path := filepath.Join(t.TempDir(), "bundle.pem")
+	if err := os.WriteFile(path, append(append(server, bad...), root...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(capturePrint(t, &PrintCmd{FilePath: path, Output: "text", Tree: true}))
+	for _, s := range []string{"===== Certificate Tree =====", "CN=MiniPKI Root", "CN=mtls.local"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected the output to contain %q, got:\n%s", s, out)
+		}
+	}
+}
+
+// capturePrint runs cmd and returns what it wrote to stdout
+func capturePrint(t *testing.T, cmd *PrintCmd) []byte {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan []byte)
+	go func() {
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		done <- buf.Bytes()
+	}()
+	runErr := cmd.Run(&Context{})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out := <-done
+	if runErr != nil {
+		t.Fatalf("print -o %s failed: %v", cmd.Output, runErr)
+	}
+	return out
+}
+
+// checkPrintEntries checks the key fields of the decoded print output
+func checkPrintEntries(t *testing.T, entries []map[string]any) {
+	t.Helper()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e["index"] != 1 && e["index"] != float64(1) {
+		t.Errorf("Expected index 1, got %v", e["index"])
+	}
+	if e["kind"] != "Certificate" || e["format"] != "PEM" {
+		t.Errorf("Expected a PEM certificate entry, got kind %v format %v", e["kind"], e["format"])
+	}
+	cert, ok := e["certificate"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected a certificate object, got %v", e["certificate"])
+	}
+	if cert["subject"] != "CN=mtls.local" || cert["issuer"] != "CN=MiniPKI Root" {
+		t.Errorf("Expected subject CN=mtls.local and issuer CN=MiniPKI Root, got %v and %v", cert["subject"], cert["issuer"])
+	}
+	for _, key := range []string{"serial", "signature_algorithm", "not_before", "not_after", "public_key", "fingerprints"} {
+		if _, ok := cert[key]; !ok {
+			t.Errorf("Expected certificate key %q", key)
+		}
+	}
+	sans, _ := cert["sans"].(map[string]any)
+	if dns, _ := sans["dns"].([]any); len(dns) != 2 || dns[0] != "mtls.local" {
+		t.Errorf("Expected sans.dns [mtls.local localhost], got %v", sans["dns"])
+	}
+	exts, _ := cert["extensions"].([]any)
+	var found bool
+	for _, x := range exts {
+		ext, _ := x.(map[string]any)
+		if ext["oid"] != "2.5.29.15" {
+			continue
+		}
+		found = true
+		if ext["name"] != "Key Usage" || ext["critical"] != true || ext["value"] != "030205a0" {
+			t.Errorf("Expected the critical Key Usage extension with value 030205a0, got %v", ext)
+		}
+		if decoded, _ := ext["decoded"].([]any); len(decoded) != 1 || decoded[0] != "DigitalSignature, KeyEncipherment" {
+			t.Errorf("Expected the decoded key usage, got %v", ext["decoded"])
+		}
+	}
+	if !found {
+		t.Errorf("Expected a Key Usage extension among %v", exts)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+// matchExitMismatch is the exit code of MatchCmd when the key does not
+// belong to the certificate or CSR. Failures to run at all exit with 1.
+const matchExitMismatch = 2
+
+type MatchCmd struct {
+	Dir    string `arg:"" optional:"" name:"dir" help:"Pair up every key, certificate and CSR found below this directory instead of checking one key." type:"existingdir"`
+	Key    string `help:"Private key (PEM or DER; PKCS#1, SEC 1, PKCS#8 or encrypted PKCS#8)." type:"existingfile"`
+	Cert   string `help:"Certificate, or chain with the leaf first, to check the key against." type:"existingfile"`
+	CSR    string `name:"csr" help:"Certificate request to check the key against." type:"existingfile"`
+	Output string `short:"o" enum:"text,json,yaml" default:"text" help:"Output format (text, json, yaml)."`
+	passwordFlags
+}
+
+func (m *MatchCmd) Run(ctx *Context) error {
+	if m.Dir != "" {
+		if m.Key != "" || m.Cert != "" || m.CSR != "" {
+			return errors.New("give either dir or --key, not both")
+		}
+		return m.runDir()
+	}
+	if m.Key == "" {
+		return errors.New("give --key with --cert and/or --csr, or a directory")
+	}
+	if m.Cert == "" && m.CSR == "" {
+		return errors.New("give --cert and/or --csr to check the key against")
+	}
+
+	key, err := m.loadKey()
+	if err != nil {
+		return err
+	}
+	var cert *x509.Certificate
+	if m.Cert != "" {
+		certs, err := loadCerts(m.Cert)
+		if err != nil {
+			return err
+		}
+		cert = certs[0]
+	}
+	var csr *x509.CertificateRequest
+	if m.CSR != "" {
+		if csr, err = loadCSR(m.CSR); err != nil {
+			return err
+		}
+	}
+
+	result, err := pki.MatchKey(key, cert, csr)
+	if err != nil {
+		return err
+	}
+	if m.Output != "text" {
+		if err := writeStructured(os.Stdout, m.Output, result); err != nil {
+			return err
+		}
+	} else {
+		fmt.Print(result.Text())
+	}
+
+	if !result.Matched {
+		os.Exit(matchExitMismatch)
+	}
+	return nil
+}
+
+func (m *MatchCmd) runDir() error {
+	var pw string
+	if m.PasswordFile != "" {
+		var err error
+		if pw, err = m.password(false); err != nil {
+			return err
+		}
+	}
+	pairing, err := pki.PairKeys(m.Dir, pw)
+	if err != nil {
+		return err
+	}
+	if m.Output != "text" {
+		return writeStructured(os.Stdout, m.Output, pairing)
+	}
+	fmt.Print(pairing.Text())
+	return nil
+}
+
+// loadKey reads the private key at m.Key, asking for its password if it
+// is encrypted.
+func (m *MatchCmd) loadKey() (crypto.Signer, error) {
+	data, err := os.ReadFile(m.Key)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := decodeInput(data, m.passwordFlags)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", m.Key, err)
+	}
+	for _, b := range decoded.Blocks {
+		if b.Kind != pki.BlockPrivateKey {
+			continue
+		}
+		key, err := pki.ParsePrivateKey(b, "")
+		if errors.Is(err, pki.ErrKeyEncrypted) {
+			var pw string
+			if pw, err = m.password(false); err != nil {
+				return nil, err
+			}
+			key, err = pki.ParsePrivateKey(b, pw)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Key, err)
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s: no private key found", m.Key)
+}
+
+// loadCSR reads the first certificate request in a PEM or DER file.
+func loadCSR(path string) (*x509.CertificateRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	der := data
+	for _, b := range pki.ReadBlocks(data) {
+		if b.Kind == pki.BlockCSR {
+			der = b.Bytes
+			break
+		}
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, fmt.Errorf("%s: not a certificate request: %w", path, err)
+	}
+	return csr, nil
+}
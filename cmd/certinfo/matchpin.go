@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+// matchPinExitNoMatch is the exit code of MatchPinCmd when no certificate
+// in the chain matches a pin. Failures to run at all exit with 1.
+const matchPinExitNoMatch = 2
+
+type MatchPinCmd struct {
+	FilePath string        `arg:"" optional:"" name:"chain-file" help:"Certificate chain to check." type:"existingfile"`
+	Target   string        `help:"Check the chain presented by this TLS endpoint (host:port; the port defaults to 443) instead of a file."`
+	Pins     []string      `name:"pin" help:"SPKI SHA-256 pin as base64, sha256/<base64>, pin-sha256=\"<base64>\" or hex (repeatable)."`
+	PinFile  string        `help:"File with one pin per line; blank lines and # comments are ignored." type:"existingfile"`
+	Timeout  time.Duration `default:"10s" help:"Dial and handshake timeout for --target."`
+	Output   string        `short:"o" enum:"text,json,yaml" default:"text" help:"Output format (text, json, yaml)."`
+}
+
+// matchPinReport is the json/yaml output of MatchPinCmd.
+type matchPinReport struct {
+	Source       string         `json:"source" yaml:"source"`
+	Matched      bool           `json:"matched" yaml:"matched"`
+	Certificates []pki.PinMatch `json:"certificates" yaml:"certificates"`
+}
+
+func (m *MatchPinCmd) Run(ctx *Context) error {
+	raw := m.Pins
+	if m.PinFile != "" {
+		more, err := readLines(m.PinFile)
+		if err != nil {
+			return err
+		}
+		raw = append(raw, more...)
+	}
+	if len(raw) == 0 {
+		return errors.New("no pins given: use --pin or --pin-file")
+	}
+	var pins []string
+	for _, r := range raw {
+		pin, err := pki.ParsePin(r)
+		if err != nil {
+			return err
+		}
+		pins = append(pins, pin)
+	}
+
+	var chain []*x509.Certificate
+	source := m.FilePath
+	switch {
+	case m.FilePath != "" && m.Target != "":
+		return errors.New("give either chain-file or --target, not both")
+	case m.Target != "":
+		info, err := pki.FetchTLS(defaultPort(m.Target), pki.FetchOptions{Timeout: m.Timeout})
+		if err != nil {
+			return err
+		}
+		chain, source = info.Certificates, m.Target
+	case m.FilePath != "":
+		var err error
+		if chain, err = loadCerts(m.FilePath); err != nil {
+			return err
+		}
+	default:
+		return errors.New("give a chain-file or --target")
+	}
+
+	results, matched := pki.MatchPins(chain, pins)
+	if m.Output != "text" {
+		report := matchPinReport{Source: source, Matched: matched, Certificates: results}
+		if err := writeStructured(os.Stdout, m.Output, report); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range results {
+			status := "no match"
+			if r.Matched {
+				status = "MATCH"
+			}
+			fmt.Printf("#%d %s\n", r.Index, r.Subject)
+			fmt.Printf("    pin-sha256=%q  %s\n", r.Pin, status)
+		}
+		if matched {
+			fmt.Println("Result:              pinned")
+		} else {
+			fmt.Println("Result:              NOT PINNED (no certificate matches a pin)")
+		}
+	}
+
+	if !matched {
+		os.Exit(matchPinExitNoMatch)
+	}
+	return nil
+}
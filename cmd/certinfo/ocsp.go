@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+type OCSPCmd struct {
+	FilePath string        `arg:"" name:"cert-file" help:"Leaf certificate, optionally followed by its issuer." type:"existingfile"`
+	Issuer   string        `help:"Issuer certificate (defaults to the second certificate in cert-file)." type:"existingfile"`
+	URL      string        `name:"url" help:"Responder URL (defaults to the certificate's OCSP server)."`
+	Response string        `help:"Read a saved DER OCSP response instead of querying the responder." type:"existingfile"`
+	Save     string        `help:"Write the raw DER response to this file." type:"path"`
+	Timeout  time.Duration `default:"10s" help:"Responder request timeout."`
+	Output   string        `short:"o" enum:"text,json,yaml" default:"text" help:"Output format (text, json, yaml)."`
+}
+
+func (o *OCSPCmd) Run(ctx *Context) error {
+	certs, err := loadCerts(o.FilePath)
+	if err != nil {
+		return err
+	}
+	leaf := certs[0]
+
+	var issuer *x509.Certificate
+	if o.Issuer != "" {
+		issuers, err := loadCerts(o.Issuer)
+		if err != nil {
+			return err
+		}
+		issuer = issuers[0]
+	} else if len(certs) > 1 {
+		issuer = certs[1]
+	} else {
+		return errors.New("issuer certificate required: pass --issuer or append it to cert-file")
+	}
+
+	var der []byte
+	if o.Response != "" {
+		der, err = os.ReadFile(o.Response)
+	} else {
+		der, err = pki.QueryOCSP(o.URL, leaf, issuer, o.Timeout)
+	}
+	if err != nil {
+		return err
+	}
+	if o.Save != "" {
+		if err := os.WriteFile(o.Save, der, 0o644); err != nil {
+			return err
+		}
+	}
+
+	info, err := pki.ParseOCSPResponse(der, leaf, issuer)
+	if err != nil {
+		return fmt.Errorf("invalid OCSP response: %w", err)
+	}
+
+	if o.Output != "text" {
+		if err := writeStructured(os.Stdout, o.Output, info); err != nil {
+			return err
+		}
+	} else {
+		fmt.Print(info.Text())
+	}
+
+	if info.Status != "good" {
+		return fmt.Errorf("certificate status is %s", info.Status)
+	}
+	return nil
+}
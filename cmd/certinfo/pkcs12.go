@@ -0,0 +1,163 @@
+package main
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+	"golang.org/x/term"
+)
+
+type PKCS12Cmd struct {
+	Show    PKCS12ShowCmd    `cmd:"" help:"Describe the certificates and key in a PKCS#12 bundle."`
+	Extract PKCS12ExtractCmd `cmd:"" help:"Write the certificates and key of a PKCS#12 bundle as PEM."`
+	Export  PKCS12ExportCmd  `cmd:"" help:"Build a PKCS#12 bundle from a certificate chain and key."`
+}
+
+// passwordFlags selects where a PKCS#12 password comes from: a file, or an
+// interactive prompt when none is given.
+type passwordFlags struct {
+	PasswordFile string `help:"Read the password from the first line of this file instead of prompting." type:"existingfile"`
+}
+
+func (f passwordFlags) password(confirm bool) (string, error) {
+	if f.PasswordFile != "" {
+		data, err := os.ReadFile(f.PasswordFile)
+		if err != nil {
+			return "", err
+		}
+		line, _, _ := strings.Cut(string(data), "\n")
+		return strings.TrimSuffix(line, "\r"), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errors.New("no terminal to prompt for the password; use --password-file")
+	}
+	fmt.Fprint(os.Stderr, "Password: ")
+	pw, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm password: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if string(again) != string(pw) {
+			return "", errors.New("passwords do not match")
+		}
+	}
+	return string(pw), nil
+}
+
+// decodePKCS12File reads and decrypts the bundle at path.
+func decodePKCS12File(path string, f passwordFlags) (*pki.PKCS12Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pw, err := f.password(false)
+	if err != nil {
+		return nil, err
+	}
+	b, err := pki.DecodePKCS12(data, pw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+type PKCS12ShowCmd struct {
+	FilePath string `arg:"" name:"bundle" help:"PKCS#12 file (.p12, .pfx)." type:"existingfile"`
+	Output   string `short:"o" enum:"text,json,yaml" default:"text" help:"Output format (text, json, yaml)."`
+	passwordFlags
+}
+
+func (p *PKCS12ShowCmd) Run(ctx *Context) error {
+	b, err := decodePKCS12File(p.FilePath, p.passwordFlags)
+	if err != nil {
+		return err
+	}
+	info := pki.GetPKCS12Info(b)
+	if p.Output != "text" {
+		return writeStructured(os.Stdout, p.Output, info)
+	}
+	fmt.Print(info.Text())
+	return nil
+}
+
+type PKCS12ExtractCmd struct {
+	FilePath string `arg:"" name:"bundle" help:"PKCS#12 file (.p12, .pfx)." type:"existingfile"`
+	Out      string `required:"" help:"Output path prefix; writes <out>.crt (chain, leaf first) and <out>.key." type:"path"`
+	passwordFlags
+}
+
+func (p *PKCS12ExtractCmd) Run(ctx *Context) error {
+	b, err := decodePKCS12File(p.FilePath, p.passwordFlags)
+	if err != nil {
+		return err
+	}
+
+	if b.Key != nil {
+		signer, ok := b.Key.(crypto.Signer)
+		if !ok {
+			return fmt.Errorf("unsupported private key type %T", b.Key)
+		}
+		keyPEM, err := pki.EncodeKeyPEM(signer)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(p.Out+".key", keyPEM, 0o600); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s.key\n", p.Out)
+	}
+	if err := os.WriteFile(p.Out+".crt", pki.EncodeCertsPEM(b.Certificates...), 0o644); err != nil { // #nosec G306 -- certificates are public
+		return err
+	}
+	fmt.Printf("Wrote %s.crt (%d certificates)\n", p.Out, len(b.Certificates))
+	return nil
+}
+
+type PKCS12ExportCmd struct {
+	Cert string `required:"" help:"Certificate chain, leaf first." type:"existingfile"`
+	Key  string `required:"" help:"Private key of the leaf (unencrypted PEM)." type:"existingfile"`
+	Out  string `required:"" help:"PKCS#12 file to write." type:"path"`
+	passwordFlags
+}
+
+func (p *PKCS12ExportCmd) Run(ctx *Context) error {
+	certs, err := loadCerts(p.Cert)
+	if err != nil {
+		return err
+	}
+	keyPEM, err := os.ReadFile(p.Key)
+	if err != nil {
+		return err
+	}
+	key, err := pki.ParsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.Key, err)
+	}
+	pw, err := p.password(true)
+	if err != nil {
+		return err
+	}
+
+	der, err := pki.EncodePKCS12(key, certs, pw)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(p.Out, der, 0o600); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s (%d certificates and key)\n", p.Out, len(certs))
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+type PKCS7Cmd struct {
+	Export PKCS7ExportCmd `cmd:"" help:"Write certificates as a PKCS#7 (.p7b) bundle."`
+}
+
+type PKCS7ExportCmd struct {
+	Certs []string `arg:"" name:"cert-file" help:"Certificate files, in chain order." type:"existingfile"`
+	Out   string   `required:"" help:"PKCS#7 file to write." type:"path"`
+	PEM   bool     `name:"pem" help:"Write a PEM \"PKCS7\" block instead of DER."`
+}
+
+func (p *PKCS7ExportCmd) Run(ctx *Context) error {
+	var chain []*x509.Certificate
+	for _, path := range p.Certs {
+		certs, err := loadCerts(path)
+		if err != nil {
+			return err
+		}
+		chain = append(chain, certs...)
+	}
+
+	encode := pki.EncodePKCS7
+	if p.PEM {
+		encode = pki.EncodePKCS7PEM
+	}
+	data, err := encode(chain)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(p.Out, data, 0o644); err != nil { // #nosec G306 -- certificates are public
+		return err
+	}
+	fmt.Printf("Wrote %s (%d certificates)\n", p.Out, len(chain))
+	return nil
+}
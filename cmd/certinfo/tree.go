@@ -0,0 +1,36 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+type TreeCmd struct {
+	Certs  []string `arg:"" name:"cert-file" help:"Certificate files, in any order." type:"existingfile"`
+	Output string   `short:"o" enum:"text,json,yaml,dot" default:"text" help:"Output format (text, json, yaml, or dot for a Graphviz graph)."`
+}
+
+func (c *TreeCmd) Run(ctx *Context) error {
+	var certs []*x509.Certificate
+	for _, path := range c.Certs {
+		more, err := loadCerts(path)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, more...)
+	}
+
+	tree := pki.BuildCertTree(certs)
+	switch c.Output {
+	case "text":
+		fmt.Print(tree.Text())
+	case "dot":
+		fmt.Print(tree.DOT())
+	default:
+		return writeStructured(os.Stdout, c.Output, tree)
+	}
+	return nil
+}
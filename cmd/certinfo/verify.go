@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+type VerifyCmd struct {
+	FilePath      string   `arg:"" name:"cert-file" help:"Leaf certificate, optionally followed by its chain." type:"existingfile"`
+	Roots         []string `help:"Trusted root bundle (repeatable). Defaults to the system roots." type:"existingfile"`
+	Intermediates []string `help:"Intermediate bundle (repeatable)." type:"existingfile"`
+	Host          string   `help:"Hostname or IP the leaf must be valid for."`
+	EKU           []string `name:"eku" default:"ServerAuth" help:"Extended key usage to require (e.g. ServerAuth, ClientAuth, Any)."`
+}
+
+func (v *VerifyCmd) Run(ctx *Context) error {
+	certs, err := loadCerts(v.FilePath)
+	if err != nil {
+		return err
+	}
+
+	opts := pki.VerifyOptions{Host: v.Host, Intermediates: certs[1:]}
+	for _, path := range v.Roots {
+		roots, err := loadCerts(path)
+		if err != nil {
+			return err
+		}
+		opts.Roots = append(opts.Roots, roots...)
+	}
+	for _, path := range v.Intermediates {
+		inter, err := loadCerts(path)
+		if err != nil {
+			return err
+		}
+		opts.Intermediates = append(opts.Intermediates, inter...)
+	}
+	for _, name := range v.EKU {
+		eku, err := pki.ParseExtKeyUsage(name)
+		if err != nil {
+			return err
+		}
+		opts.KeyUsages = append(opts.KeyUsages, eku)
+	}
+
+	res := pki.VerifyChain(certs[0], opts)
+	printVerifyResult(res)
+	if !res.OK() {
+		return errors.New("verification failed")
+	}
+	return nil
+}
+
+func printVerifyResult(res *pki.VerifyResult) {
+	fmt.Printf("Leaf:                %s\n", certName(res.Leaf))
+	fmt.Printf("EKU Checked:         %s\n", pki.JoinOrNone(res.KeyUsages))
+	if res.Host != "" {
+		if res.HostErr != nil {
+			fmt.Printf("Host:                %s: MISMATCH (%v)\n", res.Host, res.HostErr)
+		} else {
+			fmt.Printf("Host:                %s: OK\n", res.Host)
+		}
+	}
+	for i, p := range res.Paths {
+		status := "OK"
+		if p.Err != nil {
+			status = "FAILED: " + p.Err.Error()
+		}
+		fmt.Printf("Path #%d:             %s\n", i+1, status)
+		printChain(p.Certs)
+	}
+	if res.Err != nil {
+		fmt.Printf("Result:              FAILED: %v\n", res.Err)
+	} else if res.HostErr != nil {
+		fmt.Printf("Result:              FAILED: host mismatch\n")
+	} else {
+		fmt.Printf("Result:              OK\n")
+	}
+}
+
+func printChain(certs []*x509.Certificate) {
+	for i, c := range certs {
+		fmt.Printf("  %d: %s\n", i, certName(c))
+	}
+}
+
+// certName names c by its subject, or by its serial when the subject is
+// empty, as it may be for certificates that rely on SANs alone.
+func certName(c *x509.Certificate) string {
+	if s := pki.FormatName(c.Subject); s != "" {
+		return s
+	}
+	return "(empty subject, serial " + pki.GetCertInfo(c).Serial + ")"
+}
@@ -2,6 +2,13 @@ module github.com/tjarkko/go-demo
 
 go 1.25.0
 
-require github.com/alecthomas/kong v1.12.1
+require (
+	github.com/alecthomas/kong v1.12.1
+	github.com/lib/pq v1.10.9
+	golang.org/x/crypto v0.53.0
+	golang.org/x/term v0.44.0
+	gopkg.in/yaml.v3 v3.0.1
+	software.sslmate.com/src/go-pkcs12 v0.7.3
+)
 
-require github.com/lib/pq v1.10.9 // indirect
+require golang.org/x/sys v0.46.0 // indirect
@@ -0,0 +1,339 @@
+package pki
+
+import (
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxDumpDepth bounds recursion into nested and encapsulated structures.
+const maxDumpDepth = 32
+
+var asn1TagNames = map[int]string{
+	asn1.TagBoolean:         "BOOLEAN",
+	asn1.TagInteger:         "INTEGER",
+	asn1.TagBitString:       "BIT STRING",
+	asn1.TagOctetString:     "OCTET STRING",
+	asn1.TagNull:            "NULL",
+	asn1.TagOID:             "OBJECT IDENTIFIER",
+	asn1.TagEnum:            "ENUMERATED",
+	asn1.TagUTF8String:      "UTF8String",
+	asn1.TagSequence:        "SEQUENCE",
+	asn1.TagSet:             "SET",
+	asn1.TagNumericString:   "NumericString",
+	asn1.TagPrintableString: "PrintableString",
+	asn1.TagT61String:       "T61String",
+	asn1.TagIA5String:       "IA5String",
+	asn1.TagUTCTime:         "UTCTime",
+	asn1.TagGeneralizedTime: "GeneralizedTime",
+	26:                      "VisibleString",
+	asn1.TagGeneralString:   "GeneralString",
+	asn1.TagBMPString:       "BMPString",
+}
+
+var asn1ClassNames = map[int]string{
+	asn1.ClassUniversal:       "universal",
+	asn1.ClassApplication:     "application",
+	asn1.ClassContextSpecific: "context-specific",
+	asn1.ClassPrivate:         "private",
+}
+
+// ASN1Node is one tag/length/value element of a DER structure. ParseASN1
+// returns nodes depth first, so a node's children are the nodes that
+// follow it with a greater Depth.
+type ASN1Node struct {
+	// Offset is the position of the node's first header byte in the input.
+	Offset       int    `json:"offset" yaml:"offset"`
+	Depth        int    `json:"depth" yaml:"depth"`
+	HeaderLength int    `json:"header_length" yaml:"header_length"`
+	Length       int    `json:"length" yaml:"length"`
+	Class        string `json:"class" yaml:"class"`
+	Tag          int    `json:"tag" yaml:"tag"`
+	Constructed  bool   `json:"constructed" yaml:"constructed"`
+	// Type is the universal type name, e.g. "SEQUENCE", or the tag, e.g.
+	// "[0]", for other classes.
+	Type string `json:"type" yaml:"type"`
+	// Value renders the content of a primitive node. OIDs are shown with
+	// their name when known.
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+	// Encapsulates is set on an OCTET STRING or BIT STRING whose content
+	// is itself DER, as in extension values and public keys; the
+	// encapsulated nodes follow it.
+	Encapsulates bool `json:"encapsulates,omitempty" yaml:"encapsulates,omitempty"`
+}
+
+// ASN1Error reports where ParseASN1 found malformed input.
+type ASN1Error struct {
+	Offset int
+	Err    string
+}
+
+func (e *ASN1Error) Error() string {
+	return fmt.Sprintf("offset %d: %s", e.Offset, e.Err)
+}
+
+// tlvHeader is a decoded identifier and length.
+type tlvHeader struct {
+	class, tag  int
+	constructed bool
+	headerLen   int
+	length      int
+}
+
+// ParseASN1 walks der as a tree of tag/length/value elements, including
+// several concatenated top-level elements. It is more lenient than
+// encoding/asn1, accepting non-minimal lengths for instance, so that
+// malformed objects can be inspected. When the input is truncated or
+// otherwise unreadable it returns the nodes read so far with an
+// *ASN1Error.
+func ParseASN1(der []byte) ([]ASN1Node, error) {
+	var nodes []ASN1Node
+	err := parseASN1Into(&nodes, der, 0, 0)
+	return nodes, err
+}
+
+func parseASN1Into(nodes *[]ASN1Node, b []byte, base, depth int) error {
+	for off := 0; off < len(b); {
+		h, err := readTLVHeader(b[off:])
+		if err != nil {
+			return &ASN1Error{Offset: base + off, Err: err.Error()}
+		}
+		// A truncated element is still shown, with as much of its content
+		// as is available, before the error is reported.
+		start := off + h.headerLen
+		var truncated error
+		end := start + h.length
+		if avail := len(b) - start; h.length > avail {
+			truncated = &ASN1Error{Offset: base + off, Err: fmt.Sprintf("length %d exceeds the %d bytes available", h.length, avail)}
+			end = len(b)
+		}
+		content := b[start:end]
+		rv := asn1.RawValue{Class: h.class, Tag: h.tag, IsCompound: h.constructed, Bytes: content, FullBytes: b[off:end]}
+		n := ASN1Node{
+			Offset:       base + off,
+			Depth:        depth,
+			HeaderLength: h.headerLen,
+			Length:       h.length,
+			Class:        asn1ClassNames[h.class],
+			Tag:          h.tag,
+			Constructed:  h.constructed,
+			Type:         asn1TagName(rv),
+		}
+		off = end
+
+		if h.constructed {
+			*nodes = append(*nodes, n)
+			if depth >= maxDumpDepth {
+				return &ASN1Error{Offset: n.Offset, Err: fmt.Sprintf("nesting deeper than %d", maxDumpDepth)}
+			}
+			if err := parseASN1Into(nodes, content, base+start, depth+1); err != nil {
+				return err
+			}
+			if truncated != nil {
+				return truncated
+			}
+			continue
+		}
+		if truncated != nil {
+			*nodes = append(*nodes, n)
+			return truncated
+		}
+
+		// OCTET and BIT STRINGs often encapsulate DER; show the structure
+		// when they do.
+		if h.class == asn1.ClassUniversal && (h.tag == asn1.TagOctetString || h.tag == asn1.TagBitString) && depth < maxDumpDepth {
+			inner, innerBase := content, base+start
+			if h.tag == asn1.TagBitString && len(inner) > 0 && inner[0] == 0 {
+				inner, innerBase = inner[1:], innerBase+1
+			}
+			var nested []ASN1Node
+			if len(inner) > 0 && parseASN1Into(&nested, inner, innerBase, depth+1) == nil {
+				n.Encapsulates = true
+				*nodes = append(*nodes, n)
+				*nodes = append(*nodes, nested...)
+				continue
+			}
+		}
+		n.Value = asn1PrimitiveValue(rv)
+		*nodes = append(*nodes, n)
+	}
+	return nil
+}
+
+// readTLVHeader decodes the identifier and length octets at the start of b.
+// Indefinite (BER) lengths are rejected.
+func readTLVHeader(b []byte) (tlvHeader, error) {
+	var h tlvHeader
+	if len(b) < 2 {
+		return h, errors.New("truncated header")
+	}
+	h.class = int(b[0] >> 6)
+	h.constructed = b[0]&0x20 != 0
+	h.tag = int(b[0] & 0x1f)
+	i := 1
+	if h.tag == 0x1f {
+		h.tag = 0
+		for {
+			if i >= len(b) {
+				return h, errors.New("truncated tag")
+			}
+			c := b[i]
+			i++
+			h.tag = h.tag<<7 | int(c&0x7f)
+			if h.tag > 1<<24 {
+				return h, errors.New("tag number too large")
+			}
+			if c&0x80 == 0 {
+				break
+			}
+		}
+	}
+	if i >= len(b) {
+		return h, errors.New("truncated length")
+	}
+	l := b[i]
+	i++
+	if l&0x80 == 0 {
+		h.length = int(l)
+	} else {
+		n := int(l & 0x7f)
+		if n == 0 {
+			return h, errors.New("indefinite length (BER) is not supported")
+		}
+		if n > 4 {
+			return h, fmt.Errorf("%d-byte length is too large", n)
+		}
+		if i+n > len(b) {
+			return h, errors.New("truncated length")
+		}
+		for _, c := range b[i : i+n] {
+			h.length = h.length<<8 | int(c)
+		}
+		i += n
+	}
+	h.headerLen = i
+	return h, nil
+}
+
+// asn1TextValueColumn is where ASN1Text starts primitive values, relative
+// to the type column.
+const asn1TextValueColumn = 24
+
+// ASN1Text renders nodes in the layout of openssl asn1parse: offset,
+// depth, header and content length, then the type indented by depth and
+// the value of primitive nodes.
+func ASN1Text(nodes []ASN1Node) string {
+	var buf strings.Builder
+
+	for _, n := range nodes {
+		kind := "prim"
+		if n.Constructed {
+			kind = "cons"
+		}
+		label := strings.Repeat(" ", n.Depth) + n.Type
+		if len(label) >= asn1TextValueColumn {
+			label += " "
+		}
+		fmt.Fprintf(&buf, "%5d:d=%-2d hl=%d l=%4d %s: ", n.Offset, n.Depth, n.HeaderLength, n.Length, kind)
+		switch {
+		case n.Encapsulates:
+			fmt.Fprintf(&buf, "%s (encapsulates)\n", label)
+		case n.Value != "":
+			fmt.Fprintf(&buf, "%-*s:%s\n", asn1TextValueColumn, label, n.Value)
+		default:
+			fmt.Fprintf(&buf, "%s\n", label)
+		}
+	}
+
+	return buf.String()
+}
+
+// dumpASN1 renders DER as an indented outline, one element per line. Input
+// that is not well-formed DER is rendered as hex.
+func dumpASN1(der []byte) []string {
+	nodes, err := ParseASN1(der)
+	if err != nil {
+		return []string{hex.EncodeToString(der)}
+	}
+	lines := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		line := strings.Repeat("  ", n.Depth) + n.Type
+		if n.Encapsulates {
+			line += " (encapsulates)"
+		} else if n.Value != "" {
+			line += " " + n.Value
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func asn1TagName(rv asn1.RawValue) string {
+	switch rv.Class {
+	case asn1.ClassUniversal:
+		if s, ok := asn1TagNames[rv.Tag]; ok {
+			return s
+		}
+		return fmt.Sprintf("UNIVERSAL %d", rv.Tag)
+	case asn1.ClassContextSpecific:
+		return fmt.Sprintf("[%d]", rv.Tag)
+	case asn1.ClassApplication:
+		return fmt.Sprintf("[APPLICATION %d]", rv.Tag)
+	default:
+		return fmt.Sprintf("[PRIVATE %d]", rv.Tag)
+	}
+}
+
+// asn1PrimitiveValue renders the content of a primitive element.
+func asn1PrimitiveValue(rv asn1.RawValue) string {
+	if rv.Class != asn1.ClassUniversal {
+		return hex.EncodeToString(rv.Bytes)
+	}
+	switch rv.Tag {
+	case asn1.TagBoolean:
+		if len(rv.Bytes) == 1 && rv.Bytes[0] != 0 {
+			return "TRUE"
+		}
+		return "FALSE"
+	case asn1.TagInteger, asn1.TagEnum:
+		n := new(big.Int).SetBytes(rv.Bytes)
+		if len(rv.Bytes) > 0 && rv.Bytes[0]&0x80 != 0 {
+			n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(rv.Bytes)*8)))
+		}
+		if len(rv.Bytes) > 8 {
+			return hexColon(rv.Bytes)
+		}
+		return n.String()
+	case asn1.TagNull:
+		return ""
+	case asn1.TagOID:
+		var oid asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(rv.FullBytes, &oid); err == nil {
+			return FormatOID(oid.String())
+		}
+	case asn1.TagUTF8String, asn1.TagPrintableString, asn1.TagIA5String, asn1.TagNumericString,
+		asn1.TagT61String, asn1.TagUTCTime, asn1.TagGeneralizedTime, asn1.TagGeneralString,
+		asn1.TagBMPString, 26:
+		return fmt.Sprintf("%q", asn1String(rv))
+	}
+	return hex.EncodeToString(rv.Bytes)
+}
+
+// asn1String decodes the character string types found in certificates.
+func asn1String(rv asn1.RawValue) string {
+	if rv.Tag == asn1.TagBMPString {
+		if len(rv.Bytes)%2 != 0 {
+			return hex.EncodeToString(rv.Bytes)
+		}
+		u := make([]uint16, len(rv.Bytes)/2)
+		for i := range u {
+			u[i] = uint16(rv.Bytes[2*i])<<8 | uint16(rv.Bytes[2*i+1])
+		}
+		return string(utf16.Decode(u))
+	}
+	return string(rv.Bytes)
+}
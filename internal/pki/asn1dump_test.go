@@ -0,0 +1,95 @@
+package pki
+
+import (
+	"encoding/asn1"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestDumpASN1 tests the outline rendering, including encapsulated DER
+func TestDumpASN1(t *testing.T) {
+	inner, _ := asn1.Marshal(asn1.ObjectIdentifier{1, 2, 3})
+	der, _ := asn1.Marshal(struct {
+		Flag  bool
+		Name  string `asn1:"utf8"`
+		Inner []byte
+	}{true, "héllo", inner})
+
+	expected := []string{
+		"SEQUENCE",
+		"  BOOLEAN TRUE",
+		`  UTF8String "héllo"`,
+		"  OCTET STRING (encapsulates)",
+		"    OBJECT IDENTIFIER 1.2.3",
+	}
+	if got := dumpASN1(der); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+
+	if got := dumpASN1([]byte{0xde, 0xad}); len(got) != 1 || got[0] != "dead" {
+		t.Errorf("Expected hex fallback, got %q", got)
+	}
+}
+
+// TestParseASN1 tests offsets, depths and lengths of the parsed nodes
+func TestParseASN1(t *testing.T) {
+	der, _ := asn1.Marshal(struct {
+		ID   asn1.ObjectIdentifier
+		Name string `asn1:"printable"`
+	}{asn1.ObjectIdentifier{2, 5, 4, 3}, "abc"})
+
+	nodes, err := ParseASN1(der)
+	if err != nil {
+		t.Fatalf("ParseASN1 failed: %v", err)
+	}
+	expected := []ASN1Node{
+		{Offset: 0, Depth: 0, HeaderLength: 2, Length: 10, Class: "universal", Tag: 16, Constructed: true, Type: "SEQUENCE"},
+		{Offset: 2, Depth: 1, HeaderLength: 2, Length: 3, Class: "universal", Tag: 6, Type: "OBJECT IDENTIFIER", Value: "commonName (2.5.4.3)"},
+		{Offset: 7, Depth: 1, HeaderLength: 2, Length: 3, Class: "universal", Tag: 19, Type: "PrintableString", Value: `"abc"`},
+	}
+	if len(nodes) != len(expected) {
+		t.Fatalf("Expected %d nodes, got %d: %+v", len(expected), len(nodes), nodes)
+	}
+	for i := range expected {
+		if nodes[i] != expected[i] {
+			t.Errorf("Node %d: expected %+v, got %+v", i, expected[i], nodes[i])
+		}
+	}
+
+	text := ASN1Text(nodes)
+	if !strings.Contains(text, "    2:d=1  hl=2 l=   3 prim:  OBJECT IDENTIFIER      :commonName (2.5.4.3)\n") {
+		t.Errorf("Unexpected text output:\n%s", text)
+	}
+}
+
+// TestParseASN1Malformed tests that malformed input yields partial nodes
+// and the offset of the problem
+func TestParseASN1Malformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		der    []byte
+		nodes  int
+		offset int
+	}{
+		{"truncated", []byte{0x30, 0x06, 0x02, 0x01, 0x05, 0x02, 0x05, 0x01}, 3, 5},
+		{"indefinite length", []byte{0x30, 0x80, 0x02, 0x01, 0x05, 0x00, 0x00}, 0, 0},
+		{"trailing byte", []byte{0x05, 0x00, 0x30}, 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := ParseASN1(tt.der)
+			var aerr *ASN1Error
+			if !errors.As(err, &aerr) {
+				t.Fatalf("Expected *ASN1Error, got %v", err)
+			}
+			if aerr.Offset != tt.offset {
+				t.Errorf("Expected error at offset %d, got %v", tt.offset, aerr)
+			}
+			if len(nodes) != tt.nodes {
+				t.Errorf("Expected %d nodes, got %d: %+v", tt.nodes, len(nodes), nodes)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// BlockKind is the kind of object a PEM block holds.
+type BlockKind int
+
+const (
+	BlockUnknown BlockKind = iota
+	BlockCertificate
+	BlockCSR
+	BlockCRL
+	BlockPrivateKey
+	BlockPublicKey
+	BlockPKCS7
+)
+
+var blockKindNames = map[BlockKind]string{
+	BlockUnknown:     "Unknown",
+	BlockCertificate: "Certificate",
+	BlockCSR:         "Certificate Request",
+	BlockCRL:         "CRL",
+	BlockPrivateKey:  "Private Key",
+	BlockPublicKey:   "Public Key",
+	BlockPKCS7:       "PKCS#7",
+}
+
+func (k BlockKind) String() string {
+	if s, ok := blockKindNames[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("BlockKind(%d)", int(k))
+}
+
+// MarshalText lets BlockKind appear by name in JSON and YAML output.
+func (k BlockKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Block is a classified PEM block.
+type Block struct {
+	Kind    BlockKind
+	Type    string
+	Headers map[string]string
+	Bytes   []byte
+}
+
+// ClassifyBlockType maps a PEM type line to the kind of object it holds.
+func ClassifyBlockType(pemType string) BlockKind {
+	switch {
+	case pemType == "CERTIFICATE REQUEST" || pemType == "NEW CERTIFICATE REQUEST":
+		return BlockCSR
+	case strings.HasSuffix(pemType, "CERTIFICATE"):
+		return BlockCertificate
+	case pemType == "X509 CRL":
+		return BlockCRL
+	case strings.HasSuffix(pemType, "PRIVATE KEY"):
+		return BlockPrivateKey
+	case strings.HasSuffix(pemType, "PUBLIC KEY"):
+		return BlockPublicKey
+	case pemType == "PKCS7" || pemType == "PKCS #7 SIGNED DATA":
+		return BlockPKCS7
+	default:
+		return BlockUnknown
+	}
+}
+
+// ReadBlocks returns every PEM block in the input, classified. Unlike
+// ReadPEMBlocks it keeps blocks that do not hold certificates.
+func ReadBlocks(in []byte) []Block {
+	var out []Block
+	for {
+		var block *pem.Block
+		block, in = pem.Decode(in)
+		if block == nil {
+			break
+		}
+		out = append(out, Block{
+			Kind:    ClassifyBlockType(block.Type),
+			Type:    block.Type,
+			Headers: block.Headers,
+			Bytes:   block.Bytes,
+		})
+	}
+	return out
+}
+
+// Summary parses the block and renders it in the certinfo text layout.
+func (b Block) Summary() (string, error) {
+	switch b.Kind {
+	case BlockCertificate:
+		cert, err := TryParseCert(b.Bytes)
+		if err != nil {
+			return "", err
+		}
+		return GetCertInfoString(cert), nil
+	case BlockCSR:
+		csr, err := x509.ParseCertificateRequest(b.Bytes)
+		if err != nil {
+			return "", err
+		}
+		return GetCSRInfoString(csr), nil
+	case BlockCRL:
+		crl, err := x509.ParseRevocationList(b.Bytes)
+		if err != nil {
+			return "", err
+		}
+		return GetCRLInfoString(crl), nil
+	case BlockPrivateKey:
+		return privateKeySummary(b)
+	case BlockPublicKey:
+		return publicKeyBlockSummary(b)
+	case BlockPKCS7:
+		p7, err := ParsePKCS7(b.Bytes)
+		if err != nil {
+			return "", err
+		}
+		return GetPKCS7Info(p7).Text(), nil
+	default:
+		return "", fmt.Errorf("unrecognized PEM block type %q", b.Type)
+	}
+}
+
+// FormatError words a failure to parse b the way the certinfo tools report
+// it, e.g. "not a certificate: ..." or "invalid crl: ...".
+func (b Block) FormatError(err error) string {
+	switch b.Kind {
+	case BlockCertificate:
+		return fmt.Sprintf("not a certificate: %v", err)
+	case BlockUnknown:
+		return err.Error()
+	default:
+		return fmt.Sprintf("invalid %s: %v", strings.ToLower(b.Kind.String()), err)
+	}
+}
+
+// privateKeySummary describes a private key block without printing any
+// key material.
+func privateKeySummary(b Block) (string, error) {
+	if b.Type == "ENCRYPTED PRIVATE KEY" || strings.Contains(b.Headers["Proc-Type"], "ENCRYPTED") {
+		return fmt.Sprintf("Type:                %s (encrypted)\n", b.Type), nil
+	}
+
+	key, format, err := parsePrivateKeyBlock(b)
+	if err != nil {
+		return "", err
+	}
+
+	pub := publicKeyOf(key)
+	if pub == nil {
+		return "", fmt.Errorf("unsupported private key type %T", key)
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Type:                Private key (%s)\n", format)
+	fmt.Fprintf(&buf, "Public Key:          %s\n", publicKeySummary(pub))
+	if pin, err := PublicKeyPin(pub); err == nil {
+		fmt.Fprintf(&buf, "SPKI SHA-256:        %s\n", pin)
+	}
+	return buf.String(), nil
+}
+
+// parsePrivateKeyBlock parses an unencrypted private key block and names its
+// encoding.
+func parsePrivateKeyBlock(b Block) (key any, format string, err error) {
+	switch b.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(b.Bytes)
+		format = "PKCS#1"
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(b.Bytes)
+		format = "SEC 1"
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(b.Bytes)
+		format = "PKCS#8"
+	}
+	return key, format, err
+}
+
+func publicKeyBlockSummary(b Block) (string, error) {
+	var (
+		pub any
+		err error
+	)
+	if b.Type == "RSA PUBLIC KEY" {
+		pub, err = x509.ParsePKCS1PublicKey(b.Bytes)
+	} else {
+		pub, err = x509.ParsePKIXPublicKey(b.Bytes)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Public Key:          %s\n", publicKeySummary(pub))
+	if pin, err := PublicKeyPin(pub); err == nil {
+		fmt.Fprintf(&buf, "SPKI SHA-256:        %s\n", pin)
+	}
+	return buf.String(), nil
+}
+
+// publicKeyOf returns the public half of a parsed private key.
+func publicKeyOf(key any) crypto.PublicKey {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return &k.PublicKey
+	case *ecdsa.PrivateKey:
+		return &k.PublicKey
+	case ed25519.PrivateKey:
+		return k.Public()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,135 @@
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestClassifyBlockType tests PEM type classification
+func TestClassifyBlockType(t *testing.T) {
+	testCases := []struct {
+		pemType  string
+		expected BlockKind
+	}{
+		{"CERTIFICATE", BlockCertificate},
+		{"TRUSTED CERTIFICATE", BlockCertificate},
+		{"CERTIFICATE REQUEST", BlockCSR},
+		{"NEW CERTIFICATE REQUEST", BlockCSR},
+		{"X509 CRL", BlockCRL},
+		{"PRIVATE KEY", BlockPrivateKey},
+		{"RSA PRIVATE KEY", BlockPrivateKey},
+		{"EC PRIVATE KEY", BlockPrivateKey},
+		{"ENCRYPTED PRIVATE KEY", BlockPrivateKey},
+		{"PUBLIC KEY", BlockPublicKey},
+		{"PKCS7", BlockPKCS7},
+		{"DH PARAMETERS", BlockUnknown},
+	}
+
+	for _, tc := range testCases {
+		if got := ClassifyBlockType(tc.pemType); got != tc.expected {
+			t.Errorf("ClassifyBlockType(%q) = %s, expected %s", tc.pemType, got, tc.expected)
+		}
+	}
+}
+
+// TestReadBlocks tests that non-certificate blocks are kept and summarized
+func TestReadBlocks(t *testing.T) {
+	cert := createTestCertificate(t)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "csr.example.com"},
+		DNSNames: []string{"csr.example.com"},
+	}, key)
+	if err != nil {
+		t.Fatalf("Failed to create CSR: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	var data []byte
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})...)
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})...)
+
+	blocks := ReadBlocks(data)
+	if len(blocks) != 3 {
+		t.Fatalf("Expected 3 blocks, got %d", len(blocks))
+	}
+
+	expected := []struct {
+		kind     BlockKind
+		contains string
+	}{
+		{BlockCertificate, "test.example.com"},
+		{BlockCSR, "DNS=csr.example.com"},
+		{BlockPrivateKey, "ECDSA (P-256)"},
+	}
+	for i, e := range expected {
+		if blocks[i].Kind != e.kind {
+			t.Errorf("Block %d: expected kind %s, got %s", i, e.kind, blocks[i].Kind)
+		}
+		summary, err := blocks[i].Summary()
+		if err != nil {
+			t.Errorf("Block %d: unexpected error: %v", i, err)
+		}
+		if !strings.Contains(summary, e.contains) {
+			t.Errorf("Block %d: expected summary to contain '%s', got:\n%s", i, e.contains, summary)
+		}
+	}
+
+	// The key summary carries the same base64 pin as match and match-pin
+	pin, err := PublicKeyPin(key.Public())
+	if err != nil {
+		t.Fatalf("Failed to pin key: %v", err)
+	}
+	if summary, _ := blocks[2].Summary(); !strings.Contains(summary, "SPKI SHA-256:        "+pin+"\n") {
+		t.Errorf("Expected the key summary to contain pin %s, got:\n%s", pin, summary)
+	}
+}
+
+// TestCRLSummary tests the CRL renderer
+func TestCRLSummary(t *testing.T) {
+	root := newTestRoot(t, "CRL Root")
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(7),
+		ThisUpdate: time.Now(),
+		NextUpdate: time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: big.NewInt(42), RevocationTime: time.Now()},
+		},
+	}, root.cert, root.key)
+	if err != nil {
+		t.Fatalf("Failed to create CRL: %v", err)
+	}
+
+	summary, err := Block{Kind: BlockCRL, Bytes: der}.Summary()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, s := range []string{"CN=CRL Root", "CRL Number:          7", "Revoked:             1 entries"} {
+		if !strings.Contains(summary, s) {
+			t.Errorf("Expected CRL summary to contain '%s', got:\n%s", s, summary)
+		}
+	}
+}
+
+// TestUnknownBlockSummary tests that unknown blocks report their type
+func TestUnknownBlockSummary(t *testing.T) {
+	_, err := Block{Kind: BlockUnknown, Type: "DH PARAMETERS"}.Summary()
+	if err == nil || !strings.Contains(err.Error(), "DH PARAMETERS") {
+		t.Errorf("Expected error naming the PEM type, got %v", err)
+	}
+}
@@ -0,0 +1,393 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" // #nosec G505 -- RFC 5280 key identifiers
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Files kept in a CA state directory.
+const (
+	caCertFile   = "ca.crt"
+	caKeyFile    = "ca.key"
+	caChainFile  = "chain.crt"
+	caSerialFile = "serial"
+	caIssuedDir  = "issued"
+)
+
+// serialBits is the size of the random serial a new CA starts counting
+// from, keeping well above the 64 bits of entropy the CA/B Forum requires.
+const serialBits = 127
+
+// CA is a certificate authority backed by a local state directory.
+type CA struct {
+	Dir  string
+	Cert *x509.Certificate
+	Key  crypto.Signer
+	// Chain is the CA certificate followed by its parents, root last.
+	Chain []*x509.Certificate
+}
+
+// Profile describes a certificate to issue. Start from NewProfile so that
+// PathLen defaults to unconstrained.
+type Profile struct {
+	CommonName     string
+	Organization   []string
+	DNSNames       []string
+	IPAddresses    []net.IP
+	EmailAddresses []string
+	URIs           []*url.URL
+
+	KeyUsage    x509.KeyUsage
+	ExtKeyUsage []x509.ExtKeyUsage
+	Validity    time.Duration
+	IsCA        bool
+	// PathLen constrains CA profiles; -1 leaves it unconstrained.
+	PathLen int
+
+	OCSPServer            []string
+	IssuingCertificateURL []string
+	CRLDistributionPoints []string
+
+	KeyType string
+	KeyBits int
+}
+
+// profilePresets are the KU/EKU combinations selectable by name.
+var profilePresets = map[string]struct {
+	ku  x509.KeyUsage
+	eku []x509.ExtKeyUsage
+}{
+	"server":       {x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}},
+	"client":       {x509.KeyUsageDigitalSignature, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}},
+	"peer":         {x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}},
+	"code-signing": {x509.KeyUsageDigitalSignature, []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}},
+	"email":        {x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment, []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection}},
+	"ca":           {x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature, nil},
+}
+
+// NewProfile returns a profile with the key usages of the named preset
+// ("server", "client", "peer", "code-signing", "email" or "ca").
+func NewProfile(preset string) (Profile, error) {
+	p, ok := profilePresets[preset]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile %q", preset)
+	}
+	return Profile{
+		KeyUsage:    p.ku,
+		ExtKeyUsage: p.eku,
+		IsCA:        preset == "ca",
+		PathLen:     -1,
+		Validity:    90 * 24 * time.Hour,
+	}, nil
+}
+
+// GenerateKey creates a private key of the given type ("rsa", "ecdsa" or
+// "ed25519"). bits selects the RSA modulus size or the ECDSA curve
+// (256, 384, 521); zero picks RSA 2048 or P-256.
+func GenerateKey(keyType string, bits int) (crypto.Signer, error) {
+	switch strings.ToLower(keyType) {
+	case "rsa":
+		if bits == 0 {
+			bits = 2048
+		}
+		if bits < 2048 {
+			return nil, fmt.Errorf("RSA keys must be at least 2048 bits, got %d", bits)
+		}
+		return rsa.GenerateKey(rand.Reader, bits)
+	case "", "ecdsa", "ec":
+		var curve elliptic.Curve
+		switch bits {
+		case 0, 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported ECDSA curve size %d", bits)
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case "ed25519":
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", keyType)
+	}
+}
+
+// InitCA creates a CA in dir. With a nil parent the CA is a self-signed
+// root; otherwise parent signs it as an intermediate. The profile's SANs
+// and EKUs are ignored; its subject, validity, path length and AIA/CRL
+// URLs are used.
+func InitCA(dir string, p Profile, parent *CA) (*CA, error) {
+	if _, err := os.Stat(filepath.Join(dir, caCertFile)); err == nil {
+		return nil, fmt.Errorf("%s already contains a CA", dir)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, caIssuedDir), 0o700); err != nil {
+		return nil, err
+	}
+
+	key, err := GenerateKey(p.KeyType, p.KeyBits)
+	if err != nil {
+		return nil, err
+	}
+	if p.Validity == 0 {
+		p.Validity = 10 * 365 * 24 * time.Hour
+	}
+	firstSerial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), serialBits))
+	if err != nil {
+		return nil, err
+	}
+	firstSerial.SetBit(firstSerial, serialBits-1, 1)
+	template := caTemplate(p)
+
+	var cert *x509.Certificate
+	var chain []*x509.Certificate
+	if parent == nil {
+		template.SerialNumber = firstSerial
+		cert, err = createCert(template, template, key.Public(), key)
+		if err != nil {
+			return nil, err
+		}
+		chain = []*x509.Certificate{cert}
+	} else {
+		cert, err = parent.sign(template, key.Public())
+		if err != nil {
+			return nil, err
+		}
+		chain = append([]*x509.Certificate{cert}, parent.Chain...)
+	}
+
+	if err := writeKey(filepath.Join(dir, caKeyFile), key); err != nil {
+		return nil, err
+	}
+	if err := writeCerts(filepath.Join(dir, caCertFile), cert); err != nil {
+		return nil, err
+	}
+	if err := writeCerts(filepath.Join(dir, caChainFile), chain...); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, caSerialFile), []byte(firstSerial.Text(16)+"\n"), 0o600); err != nil {
+		return nil, err
+	}
+	return &CA{Dir: dir, Cert: cert, Key: key, Chain: chain}, nil
+}
+
+// LoadCA opens a CA created by InitCA.
+func LoadCA(dir string) (*CA, error) {
+	chainPEM, err := os.ReadFile(filepath.Join(dir, caChainFile))
+	if err != nil {
+		return nil, err
+	}
+	chain, err := ParseCertificates(chainPEM)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(dir, caKeyFile))
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{Dir: dir, Cert: chain[0], Key: key, Chain: chain}, nil
+}
+
+// Issue generates a key and signs a certificate for it according to p.
+// The certificate is also recorded under the CA's issued/ directory.
+func (ca *CA) Issue(p Profile) (*x509.Certificate, crypto.Signer, error) {
+	if p.CommonName == "" && len(p.DNSNames) == 0 && len(p.IPAddresses) == 0 &&
+		len(p.EmailAddresses) == 0 && len(p.URIs) == 0 {
+		return nil, nil, errors.New("profile needs a common name or at least one SAN")
+	}
+	key, err := GenerateKey(p.KeyType, p.KeyBits)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.Validity == 0 {
+		p.Validity = 90 * 24 * time.Hour
+	}
+
+	var template *x509.Certificate
+	if p.IsCA {
+		template = caTemplate(p)
+	} else {
+		template = &x509.Certificate{
+			Subject:               pkix.Name{CommonName: p.CommonName, Organization: p.Organization},
+			NotBefore:             time.Now().Add(-5 * time.Minute),
+			NotAfter:              time.Now().Add(p.Validity),
+			KeyUsage:              p.KeyUsage,
+			ExtKeyUsage:           p.ExtKeyUsage,
+			BasicConstraintsValid: true,
+			OCSPServer:            p.OCSPServer,
+			IssuingCertificateURL: p.IssuingCertificateURL,
+			CRLDistributionPoints: p.CRLDistributionPoints,
+		}
+	}
+	template.DNSNames = p.DNSNames
+	template.IPAddresses = p.IPAddresses
+	template.EmailAddresses = p.EmailAddresses
+	template.URIs = p.URIs
+	if _, ok := key.(*rsa.PrivateKey); !ok {
+		// KeyEncipherment only applies to RSA key transport.
+		template.KeyUsage &^= x509.KeyUsageKeyEncipherment
+	}
+
+	cert, err := ca.sign(template, key.Public())
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// sign assigns the next serial, signs template and records the result.
+func (ca *CA) sign(template *x509.Certificate, pub crypto.PublicKey) (*x509.Certificate, error) {
+	serial, err := ca.nextSerial()
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serial
+	if len(template.SubjectKeyId) == 0 {
+		// x509 only fills in the SKI for CA certificates.
+		if template.SubjectKeyId, err = subjectKeyID(pub); err != nil {
+			return nil, err
+		}
+	}
+	if template.NotAfter.After(ca.Cert.NotAfter) {
+		template.NotAfter = ca.Cert.NotAfter
+	}
+	cert, err := createCert(template, ca.Cert, pub, ca.Key)
+	if err != nil {
+		return nil, err
+	}
+	issued := filepath.Join(ca.Dir, caIssuedDir, fmt.Sprintf("%x.crt", serial))
+	if err := writeCerts(issued, cert); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// nextSerial reads and advances the persisted serial counter.
+func (ca *CA) nextSerial() (*big.Int, error) {
+	path := filepath.Join(ca.Dir, caSerialFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	serial, ok := new(big.Int).SetString(strings.TrimSpace(string(data)), 16)
+	if !ok {
+		return nil, fmt.Errorf("%s: malformed serial %q", path, strings.TrimSpace(string(data)))
+	}
+	// A self-signed CA used the stored value for its own certificate.
+	serial.Add(serial, big.NewInt(1))
+	if err := os.WriteFile(path, []byte(serial.Text(16)+"\n"), 0o600); err != nil {
+		return nil, err
+	}
+	return serial, nil
+}
+
+func caTemplate(p Profile) *x509.Certificate {
+	t := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: p.CommonName, Organization: p.Organization},
+		NotBefore:             time.Now().Add(-5 * time.Minute),
+		NotAfter:              time.Now().Add(p.Validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLen:            p.PathLen,
+		MaxPathLenZero:        p.PathLen == 0,
+		OCSPServer:            p.OCSPServer,
+		IssuingCertificateURL: p.IssuingCertificateURL,
+		CRLDistributionPoints: p.CRLDistributionPoints,
+	}
+	if p.PathLen < 0 {
+		t.MaxPathLen = -1
+	}
+	return t
+}
+
+// subjectKeyID computes the RFC 5280 method 1 key identifier: the SHA-1 of
+// the subjectPublicKey bit string.
+func subjectKeyID(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum(spki.PublicKey.Bytes) // #nosec G401 -- RFC 5280 key identifier, not a security use
+	return sum[:], nil
+}
+
+func createCert(template, parent *x509.Certificate, pub crypto.PublicKey, signer crypto.Signer) (*x509.Certificate, error) {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+// EncodeCertsPEM encodes certificates as concatenated PEM blocks.
+func EncodeCertsPEM(certs ...*x509.Certificate) []byte {
+	var out []byte
+	for _, c := range certs {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+	}
+	return out
+}
+
+// EncodeKeyPEM encodes a private key as a PKCS#8 "PRIVATE KEY" block.
+func EncodeKeyPEM(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func writeCerts(path string, certs ...*x509.Certificate) error {
+	return os.WriteFile(path, EncodeCertsPEM(certs...), 0o644) // #nosec G306 -- certificates are public
+}
+
+func writeKey(path string, key crypto.Signer) error {
+	data, err := EncodeKeyPEM(key)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// ParsePrivateKeyPEM parses the first private key block in data. Encrypted
+// keys return ErrKeyEncrypted.
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	for _, b := range ReadBlocks(data) {
+		if b.Kind == BlockPrivateKey {
+			return ParsePrivateKey(b, "")
+		}
+	}
+	return nil, errors.New("no private key found")
+}
@@ -0,0 +1,148 @@
+package pki
+
+import (
+	"crypto/x509"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newCAProfile returns a CA profile with the given common name
+func newCAProfile(t *testing.T, cn string) Profile {
+	t.Helper()
+	p, err := NewProfile("ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.CommonName = cn
+	return p
+}
+
+// TestCAIssueChain tests root -> intermediate -> leaf issuance for each key type
+func TestCAIssueChain(t *testing.T) {
+	dir := t.TempDir()
+
+	root, err := InitCA(filepath.Join(dir, "root"), newCAProfile(t, "Test Root"), nil)
+	if err != nil {
+		t.Fatalf("InitCA(root) failed: %v", err)
+	}
+
+	ip := newCAProfile(t, "Test Intermediate")
+	ip.PathLen = 0
+	ip.CRLDistributionPoints = []string{"http://crl.example.com/root.crl"}
+	inter, err := InitCA(filepath.Join(dir, "inter"), ip, root)
+	if err != nil {
+		t.Fatalf("InitCA(intermediate) failed: %v", err)
+	}
+	if !inter.Cert.MaxPathLenZero {
+		t.Error("Expected intermediate to have path length 0")
+	}
+	if len(inter.Chain) != 2 {
+		t.Errorf("Expected intermediate chain of 2, got %d", len(inter.Chain))
+	}
+
+	for _, keyType := range []string{"rsa", "ecdsa", "ed25519"} {
+		p, err := NewProfile("server")
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.CommonName = "www.example.com"
+		p.DNSNames = []string{"www.example.com"}
+		p.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+		p.OCSPServer = []string{"http://ocsp.example.com"}
+		p.KeyType = keyType
+
+		leaf, key, err := inter.Issue(p)
+		if err != nil {
+			t.Fatalf("%s: Issue failed: %v", keyType, err)
+		}
+		if key == nil {
+			t.Fatalf("%s: expected a private key", keyType)
+		}
+		if len(leaf.SubjectKeyId) == 0 {
+			t.Errorf("%s: expected leaf to carry a subject key ID", keyType)
+		}
+		if leaf.OCSPServer[0] != "http://ocsp.example.com" {
+			t.Errorf("%s: expected OCSP URL to be embedded", keyType)
+		}
+
+		res := VerifyChain(leaf, VerifyOptions{
+			Roots:         []*x509.Certificate{root.Cert},
+			Intermediates: []*x509.Certificate{inter.Cert},
+			Host:          "www.example.com",
+		})
+		if !res.OK() {
+			t.Errorf("%s: expected issued chain to verify, got %v / %v", keyType, res.Err, res.HostErr)
+		}
+	}
+}
+
+// TestCASerialsPersist tests that serials keep increasing across LoadCA
+func TestCASerialsPersist(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := InitCA(dir, newCAProfile(t, "Serial Root"), nil); err != nil {
+		t.Fatalf("InitCA failed: %v", err)
+	}
+
+	p, err := NewProfile("client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.CommonName = "client"
+
+	var last *x509.Certificate
+	for i := 0; i < 2; i++ {
+		ca, err := LoadCA(dir)
+		if err != nil {
+			t.Fatalf("LoadCA failed: %v", err)
+		}
+		cert, _, err := ca.Issue(p)
+		if err != nil {
+			t.Fatalf("Issue failed: %v", err)
+		}
+		if last != nil && cert.SerialNumber.Cmp(last.SerialNumber) <= 0 {
+			t.Errorf("Expected serial %s to be greater than %s", cert.SerialNumber, last.SerialNumber)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "issued", cert.SerialNumber.Text(16)+".crt")); err != nil {
+			t.Errorf("Expected issued certificate to be recorded: %v", err)
+		}
+		last = cert
+	}
+}
+
+// TestInitCAExisting tests that an existing CA is not overwritten
+func TestInitCAExisting(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := InitCA(dir, newCAProfile(t, "Root"), nil); err != nil {
+		t.Fatalf("InitCA failed: %v", err)
+	}
+	if _, err := InitCA(dir, newCAProfile(t, "Root"), nil); err == nil {
+		t.Error("Expected InitCA to refuse an existing CA directory")
+	}
+}
+
+// TestGenerateKey tests key type selection
+func TestGenerateKey(t *testing.T) {
+	testCases := []struct {
+		keyType string
+		bits    int
+		summary string
+	}{
+		{"rsa", 0, "RSA (2048 bits)"},
+		{"ecdsa", 384, "ECDSA (P-384)"},
+		{"ed25519", 0, "Ed25519"},
+	}
+	for _, tc := range testCases {
+		key, err := GenerateKey(tc.keyType, tc.bits)
+		if err != nil {
+			t.Fatalf("GenerateKey(%s, %d) failed: %v", tc.keyType, tc.bits, err)
+		}
+		if got := publicKeySummary(key.Public()); got != tc.summary {
+			t.Errorf("GenerateKey(%s, %d) = %s, expected %s", tc.keyType, tc.bits, got, tc.summary)
+		}
+	}
+	if _, err := GenerateKey("rsa", 1024); err == nil {
+		t.Error("Expected error for 1024-bit RSA")
+	}
+}
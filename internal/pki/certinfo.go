@@ -0,0 +1,264 @@
+package pki
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CertInfo is the structured view of a certificate that every renderer
+// (text, JSON, HTML) works from.
+type CertInfo struct {
+	Subject            string      `json:"subject" yaml:"subject"`
+	Issuer             string      `json:"issuer" yaml:"issuer"`
+	Serial             string      `json:"serial" yaml:"serial"`
+	Version            int         `json:"version" yaml:"version"`
+	SignatureAlgorithm string      `json:"signature_algorithm" yaml:"signature_algorithm"`
+	PublicKey          KeyInfo     `json:"public_key" yaml:"public_key"`
+	NotBefore          time.Time   `json:"not_before" yaml:"not_before"`
+	NotAfter           time.Time   `json:"not_after" yaml:"not_after"`
+	IsCA               bool        `json:"is_ca" yaml:"is_ca"`
+	PathLen            *int        `json:"path_len,omitempty" yaml:"path_len,omitempty"`
+	KeyUsage           []string    `json:"key_usage,omitempty" yaml:"key_usage,omitempty"`
+	ExtKeyUsage        []string    `json:"ext_key_usage,omitempty" yaml:"ext_key_usage,omitempty"`
+	SANs               SANs        `json:"sans" yaml:"sans"`
+	SubjectKeyID       string      `json:"subject_key_id,omitempty" yaml:"subject_key_id,omitempty"`
+	AuthorityKeyID     string      `json:"authority_key_id,omitempty" yaml:"authority_key_id,omitempty"`
+	OCSPServers        []string    `json:"ocsp_servers,omitempty" yaml:"ocsp_servers,omitempty"`
+	CRLDistribution    []string    `json:"crl_distribution_points,omitempty" yaml:"crl_distribution_points,omitempty"`
+	IssuingCertURLs    []string    `json:"issuing_certificate_urls,omitempty" yaml:"issuing_certificate_urls,omitempty"`
+	PolicyOIDs         []string    `json:"policy_oids,omitempty" yaml:"policy_oids,omitempty"`
+	Extensions         []Extension `json:"extensions,omitempty" yaml:"extensions,omitempty"`
+	Fingerprints       Fingerprint `json:"fingerprints" yaml:"fingerprints"`
+	CanVerifyChains    bool        `json:"can_verify_chains" yaml:"can_verify_chains"`
+}
+
+// KeyInfo describes a public key.
+type KeyInfo struct {
+	Algorithm string `json:"algorithm" yaml:"algorithm"`
+	Bits      int    `json:"bits,omitempty" yaml:"bits,omitempty"`
+	Curve     string `json:"curve,omitempty" yaml:"curve,omitempty"`
+	Summary   string `json:"summary" yaml:"summary"`
+}
+
+// SANs holds the subject alternative names by type.
+type SANs struct {
+	DNS   []string `json:"dns,omitempty" yaml:"dns,omitempty"`
+	Email []string `json:"email,omitempty" yaml:"email,omitempty"`
+	IP    []string `json:"ip,omitempty" yaml:"ip,omitempty"`
+	URI   []string `json:"uri,omitempty" yaml:"uri,omitempty"`
+}
+
+// Extension is a single certificate extension. Value is the hex-encoded
+// DER extnValue; Decoded renders it readably, as an ASN.1 dump when the
+// extension is not one Name is known for.
+type Extension struct {
+	OID      string   `json:"oid" yaml:"oid"`
+	Name     string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Critical bool     `json:"critical" yaml:"critical"`
+	Value    string   `json:"value" yaml:"value"`
+	Decoded  []string `json:"decoded,omitempty" yaml:"decoded,omitempty"`
+}
+
+// Fingerprint holds digests of the DER-encoded certificate and
+// identifiers derived from its public key.
+type Fingerprint struct {
+	SHA1   string `json:"sha1" yaml:"sha1"`
+	SHA256 string `json:"sha256" yaml:"sha256"`
+	SHA384 string `json:"sha384" yaml:"sha384"`
+	SHA512 string `json:"sha512" yaml:"sha512"`
+	// SPKISHA256 is the base64 RFC 7469 pin of the public key.
+	SPKISHA256 string  `json:"spki_sha256" yaml:"spki_sha256"`
+	KeyIDs     []KeyID `json:"key_ids,omitempty" yaml:"key_ids,omitempty"`
+	// SubjectKeyIDMethod names the KeyIDs method that reproduces the
+	// certificate's Subject Key Identifier, if any does.
+	SubjectKeyIDMethod string `json:"subject_key_id_method,omitempty" yaml:"subject_key_id_method,omitempty"`
+}
+
+// GetCertInfo extracts the structured details of c.
+func GetCertInfo(c *x509.Certificate) *CertInfo {
+	ci := &CertInfo{
+		Subject:            FormatName(c.Subject),
+		Issuer:             FormatName(c.Issuer),
+		Serial:             hexifyBigInt(c.SerialNumber),
+		Version:            c.Version,
+		SignatureAlgorithm: c.SignatureAlgorithm.String(),
+		PublicKey:          publicKeyInfo(c.PublicKey),
+		NotBefore:          c.NotBefore,
+		NotAfter:           c.NotAfter,
+		IsCA:               c.IsCA,
+		KeyUsage:           keyUsageToStrings(c.KeyUsage),
+		ExtKeyUsage:        extKeyUsageToStrings(c.ExtKeyUsage),
+		SANs:               sansOf(c),
+		OCSPServers:        c.OCSPServer,
+		CRLDistribution:    c.CRLDistributionPoints,
+		IssuingCertURLs:    c.IssuingCertificateURL,
+		CanVerifyChains:    canVerifyChains(c),
+	}
+
+	if c.MaxPathLenZero {
+		zero := 0
+		ci.PathLen = &zero
+	} else if c.MaxPathLen > 0 {
+		n := c.MaxPathLen
+		ci.PathLen = &n
+	}
+
+	if len(c.SubjectKeyId) > 0 {
+		ci.SubjectKeyID = hexColon(c.SubjectKeyId)
+	}
+	if len(c.AuthorityKeyId) > 0 {
+		ci.AuthorityKeyID = hexColon(c.AuthorityKeyId)
+	}
+
+	// crypto/x509 leaves extended key usages it does not know as OIDs.
+	for _, oid := range c.UnknownExtKeyUsage {
+		ci.ExtKeyUsage = append(ci.ExtKeyUsage, FormatOID(oid.String()))
+	}
+
+	for _, oid := range c.PolicyIdentifiers {
+		ci.PolicyOIDs = append(ci.PolicyOIDs, oid.String())
+	}
+
+	for _, e := range c.Extensions {
+		ci.Extensions = append(ci.Extensions, newExtension(e))
+	}
+
+	ci.Fingerprints = fingerprintsOf(c)
+
+	return ci
+}
+
+func sansOf(c *x509.Certificate) SANs {
+	var s SANs
+	s.DNS = c.DNSNames
+	s.Email = c.EmailAddresses
+	for _, ip := range c.IPAddresses {
+		s.IP = append(s.IP, ip.String())
+	}
+	for _, u := range c.URIs {
+		s.URI = append(s.URI, safeURI(u))
+	}
+	return s
+}
+
+// Text renders ci in the human-readable layout used by the certinfo tools,
+// with the SHA-256 fingerprint.
+func (ci *CertInfo) Text() string {
+	return ci.TextWithFingerprints([]string{FingerprintSHA256})
+}
+
+// TextWithFingerprints is Text showing the fingerprints of the given
+// algorithms, from FingerprintAlgorithms.
+func (ci *CertInfo) TextWithFingerprints(algs []string) string {
+	var buf strings.Builder
+
+	fmt.Fprintf(&buf, "Subject:             %s\n", ci.Subject)
+	fmt.Fprintf(&buf, "Issuer:              %s\n", ci.Issuer)
+	fmt.Fprintf(&buf, "Serial:              %s\n", ci.Serial)
+	fmt.Fprintf(&buf, "Version:             %d (X.509v%d)\n", ci.Version, ci.Version)
+	fmt.Fprintf(&buf, "Signature Algorithm: %s\n", ci.SignatureAlgorithm)
+	fmt.Fprintf(&buf, "Public Key:          %s\n", ci.PublicKey.Summary)
+	fmt.Fprintf(&buf, "Validity:\n")
+	fmt.Fprintf(&buf, "  Not Before:        %s\n", ci.NotBefore.Format(time.RFC3339))
+	fmt.Fprintf(&buf, "  Not After:         %s\n", ci.NotAfter.Format(time.RFC3339))
+	fmt.Fprintf(&buf, "Is CA:               %t\n", ci.IsCA)
+	if ci.PathLen != nil {
+		if *ci.PathLen == 0 {
+			fmt.Fprintf(&buf, "Path Len:            0 (MaxPathLenZero)\n")
+		} else {
+			fmt.Fprintf(&buf, "Path Len:            %d\n", *ci.PathLen)
+		}
+	}
+
+	if len(ci.KeyUsage) > 0 {
+		fmt.Fprintf(&buf, "Key Usage:           %s\n", strings.Join(ci.KeyUsage, ", "))
+	}
+	if len(ci.ExtKeyUsage) > 0 {
+		fmt.Fprintf(&buf, "Extended Key Usage:  %s\n", strings.Join(ci.ExtKeyUsage, ", "))
+	}
+
+	// SANs
+	if sans := ci.SANs.String(); sans != "" {
+		fmt.Fprintf(&buf, "Subject Alt Names:   %s\n", sans)
+	}
+
+	// IDs
+	if ci.SubjectKeyID != "" {
+		fmt.Fprintf(&buf, "Subject Key ID:      %s\n", ci.SubjectKeyID)
+	}
+	if ci.AuthorityKeyID != "" {
+		fmt.Fprintf(&buf, "Authority Key ID:    %s\n", ci.AuthorityKeyID)
+	}
+
+	// AIA / CRL / OCSP
+	if len(ci.OCSPServers) > 0 {
+		fmt.Fprintf(&buf, "OCSP:                %s\n", strings.Join(ci.OCSPServers, ", "))
+	}
+	if len(ci.CRLDistribution) > 0 {
+		fmt.Fprintf(&buf, "CRL Distribution:    %s\n", strings.Join(ci.CRLDistribution, ", "))
+	}
+	if len(ci.IssuingCertURLs) > 0 {
+		fmt.Fprintf(&buf, "AIA Issuer URL:      %s\n", strings.Join(ci.IssuingCertURLs, ", "))
+	}
+	if len(ci.PolicyOIDs) > 0 {
+		fmt.Fprintf(&buf, "Policy OIDs:         %s\n", strings.Join(formatOIDs(ci.PolicyOIDs), ", "))
+	}
+
+	// Fingerprints
+	buf.WriteString(fingerprintText(ci.Fingerprints, algs))
+
+	// Extensions, decoded
+	if len(ci.Extensions) > 0 {
+		fmt.Fprintf(&buf, "Extensions:\n")
+		for _, e := range ci.Extensions {
+			fmt.Fprintf(&buf, "  %s\n", e.heading())
+			for _, line := range e.Decoded {
+				fmt.Fprintf(&buf, "      %s\n", line)
+			}
+		}
+	}
+
+	// Chain-building hints
+	fmt.Fprintf(&buf, "Can Verify Chains:   %t\n", ci.CanVerifyChains)
+
+	return buf.String()
+}
+
+// heading names e as "Name (OID, critical):", or by OID alone when its
+// name is not known.
+func (e Extension) heading() string {
+	name := e.Name
+	var attrs []string
+	if name == "" {
+		name = e.OID
+	} else {
+		attrs = append(attrs, e.OID)
+	}
+	if e.Critical {
+		attrs = append(attrs, "critical")
+	}
+	if len(attrs) == 0 {
+		return name + ":"
+	}
+	return fmt.Sprintf("%s (%s):", name, strings.Join(attrs, ", "))
+}
+
+// String joins the SANs in the "DNS=a,b | IP=c" form.
+func (s SANs) String() string {
+	var parts []string
+	if len(s.DNS) > 0 {
+		parts = append(parts, "DNS="+strings.Join(s.DNS, ","))
+	}
+	if len(s.Email) > 0 {
+		parts = append(parts, "Email="+strings.Join(s.Email, ","))
+	}
+	if len(s.IP) > 0 {
+		parts = append(parts, "IP="+strings.Join(s.IP, ","))
+	}
+	if len(s.URI) > 0 {
+		parts = append(parts, "URI="+strings.Join(s.URI, ","))
+	}
+	return strings.Join(parts, " | ")
+}
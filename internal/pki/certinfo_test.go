@@ -0,0 +1,57 @@
+package pki
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestGetCertInfo tests that the structured model carries the certificate fields
+func TestGetCertInfo(t *testing.T) {
+	cert := createTestCertificate(t)
+
+	ci := GetCertInfo(cert)
+
+	if ci.Subject != "CN=test.example.com" {
+		t.Errorf("Expected subject 'CN=test.example.com', got '%s'", ci.Subject)
+	}
+	if ci.Serial != "01" {
+		t.Errorf("Expected serial '01', got '%s'", ci.Serial)
+	}
+	if ci.PublicKey.Algorithm != "RSA" || ci.PublicKey.Bits != 2048 {
+		t.Errorf("Expected RSA 2048 key, got %+v", ci.PublicKey)
+	}
+	if len(ci.ExtKeyUsage) != 1 || ci.ExtKeyUsage[0] != "ServerAuth" {
+		t.Errorf("Expected ExtKeyUsage [ServerAuth], got %v", ci.ExtKeyUsage)
+	}
+	if len(ci.Extensions) != len(cert.Extensions) {
+		t.Errorf("Expected %d extensions, got %d", len(cert.Extensions), len(ci.Extensions))
+	}
+	if ci.Fingerprints.SHA256 == "" {
+		t.Error("Expected SHA-256 fingerprint")
+	}
+}
+
+// TestCertInfoText tests that the text renderer matches GetCertInfoString
+func TestCertInfoText(t *testing.T) {
+	cert := createTestCertificate(t)
+
+	text := GetCertInfo(cert).Text()
+	if text != GetCertInfoString(cert) {
+		t.Error("Expected Text() to match GetCertInfoString")
+	}
+	if !strings.Contains(text, "Extended Key Usage:  ServerAuth\n") {
+		t.Errorf("Expected EKU line in output, got:\n%s", text)
+	}
+}
+
+// TestSANsString tests the one-line SAN rendering
+func TestSANsString(t *testing.T) {
+	s := SANs{DNS: []string{"a.example", "b.example"}, IP: []string{"127.0.0.1"}}
+	expected := "DNS=a.example,b.example | IP=127.0.0.1"
+	if got := s.String(); got != expected {
+		t.Errorf("SANs.String() = %s, expected %s", got, expected)
+	}
+	if got := (SANs{}).String(); got != "" {
+		t.Errorf("Expected empty string for no SANs, got '%s'", got)
+	}
+}
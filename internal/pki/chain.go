@@ -0,0 +1,396 @@
+package pki
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxAIAResponseSize bounds how much of an AIA caIssuers download is read.
+const maxAIAResponseSize = 1 << 20
+
+// ChainOptions configures BuildChain.
+type ChainOptions struct {
+	// FetchAIA downloads the issuer of the topmost certificate from its
+	// AIA caIssuers URLs when the input does not contain it.
+	FetchAIA bool
+	Timeout  time.Duration
+	// Roots are the trust anchors a chain may stop below, as servers send
+	// it without the root. When empty the system trust store is used.
+	Roots []*x509.Certificate
+}
+
+// ChainEntry is one certificate of an ordered chain, or one left out of
+// it.
+type ChainEntry struct {
+	Subject    string    `json:"subject" yaml:"subject"`
+	Issuer     string    `json:"issuer" yaml:"issuer"`
+	Serial     string    `json:"serial" yaml:"serial"`
+	NotAfter   time.Time `json:"not_after" yaml:"not_after"`
+	SelfSigned bool      `json:"self_signed" yaml:"self_signed"`
+	// Index is the certificate's 1-based position in the input, or 0 when
+	// it was fetched from FetchedFrom.
+	Index       int    `json:"index,omitempty" yaml:"index,omitempty"`
+	FetchedFrom string `json:"fetched_from,omitempty" yaml:"fetched_from,omitempty"`
+}
+
+// MissingIssuer describes the certificate a chain needs next but that
+// could not be found.
+type MissingIssuer struct {
+	Subject        string   `json:"subject" yaml:"subject"`
+	AuthorityKeyID string   `json:"authority_key_id,omitempty" yaml:"authority_key_id,omitempty"`
+	URLs           []string `json:"urls,omitempty" yaml:"urls,omitempty"`
+}
+
+// ChainResult is the outcome of BuildChain.
+type ChainResult struct {
+	// Chain is the ordered chain, leaf first; Entries describes it.
+	Chain   []*x509.Certificate `json:"-" yaml:"-"`
+	Entries []ChainEntry        `json:"chain" yaml:"chain"`
+	// Complete is set when the chain ends at a self-signed certificate,
+	// or just below a trusted root that was left out of it; RootOmitted
+	// marks the latter and Anchor names that root.
+	Complete    bool   `json:"complete" yaml:"complete"`
+	RootOmitted bool   `json:"root_omitted" yaml:"root_omitted"`
+	Anchor      string `json:"anchor,omitempty" yaml:"anchor,omitempty"`
+	// Reordered is set when the certificates taken from the input were
+	// not already in chain order.
+	Reordered  bool           `json:"reordered" yaml:"reordered"`
+	Missing    *MissingIssuer `json:"missing,omitempty" yaml:"missing,omitempty"`
+	Extraneous []ChainEntry   `json:"extraneous,omitempty" yaml:"extraneous,omitempty"`
+	Duplicates int            `json:"duplicates,omitempty" yaml:"duplicates,omitempty"`
+	// Errors lists AIA downloads that failed.
+	Errors []string `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+// BuildChain orders an unordered set of certificates from the leaf towards
+// the root. Issuers are matched by name, AKI/SKI and signature. The leaf is
+// the certificate that issued none of the others, preferring end-entity
+// certificates and then the one with the longest chain; where several
+// certificates could issue the next one, as with cross-signed CAs, the
+// one leading to the longer chain is used. A chain that stops below a
+// trusted root is complete, since servers leave the root out; only a
+// missing intermediate, or an untrusted root, is reported as Missing.
+// Certificates that do not belong to the chain are reported as extraneous.
+func BuildChain(certs []*x509.Certificate, opts ChainOptions) (*ChainResult, error) {
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates given")
+	}
+	res := &ChainResult{}
+
+	var pool []*x509.Certificate
+	index := map[*x509.Certificate]int{}
+	for i, c := range certs {
+		if containsCert(pool, c) {
+			res.Duplicates++
+			continue
+		}
+		pool = append(pool, c)
+		index[c] = i + 1
+	}
+
+	g := newIssuerGraph(pool)
+	leaf := g.chooseLeaf()
+	chain := []*x509.Certificate{leaf}
+	fetched := map[*x509.Certificate]string{}
+	for len(chain) < maxChainDepth {
+		top := chain[len(chain)-1]
+		if isSelfSigned(top) {
+			res.Complete = true
+			break
+		}
+		if issuer := g.longestIssuer(top, chain); issuer != nil {
+			chain = append(chain, issuer)
+			continue
+		}
+		if anchor := trustAnchor(top, opts.Roots); anchor != nil {
+			res.Complete, res.RootOmitted = true, true
+			res.Anchor = FormatName(anchor.Subject)
+			break
+		}
+		if opts.FetchAIA {
+			if issuer, from := fetchIssuer(top, opts.Timeout, res); issuer != nil {
+				fetched[issuer] = from
+				chain = append(chain, issuer)
+				continue
+			}
+		}
+		res.Missing = &MissingIssuer{
+			Subject: FormatName(top.Issuer),
+			URLs:    top.IssuingCertificateURL,
+		}
+		if len(top.AuthorityKeyId) > 0 {
+			res.Missing.AuthorityKeyID = hexColon(top.AuthorityKeyId)
+		}
+		break
+	}
+
+	res.Chain = chain
+	last := 0
+	for _, c := range chain {
+		e := chainEntry(c, index[c])
+		e.FetchedFrom = fetched[c]
+		res.Entries = append(res.Entries, e)
+		if e.Index != 0 {
+			if e.Index < last {
+				res.Reordered = true
+			}
+			last = e.Index
+		}
+	}
+	for _, c := range pool {
+		if !containsCert(chain, c) {
+			res.Extraneous = append(res.Extraneous, chainEntry(c, index[c]))
+		}
+	}
+	return res, nil
+}
+
+func chainEntry(c *x509.Certificate, index int) ChainEntry {
+	return ChainEntry{
+		Subject:    FormatName(c.Subject),
+		Issuer:     FormatName(c.Issuer),
+		Serial:     hexifyBigInt(c.SerialNumber),
+		NotAfter:   c.NotAfter,
+		SelfSigned: isSelfSigned(c),
+		Index:      index,
+	}
+}
+
+// issuerGraph records which certificates of a pool issued which, and the
+// length of the longest chain above each, so that BuildChain checks every
+// signature once however densely the CAs cross-signed each other.
+type issuerGraph struct {
+	pool     []*x509.Certificate
+	issuers  map[*x509.Certificate][]*x509.Certificate
+	issued   map[*x509.Certificate]bool
+	length   map[*x509.Certificate]int
+	visiting map[*x509.Certificate]bool
+}
+
+func newIssuerGraph(pool []*x509.Certificate) *issuerGraph {
+	g := &issuerGraph{
+		pool:     pool,
+		issuers:  map[*x509.Certificate][]*x509.Certificate{},
+		issued:   map[*x509.Certificate]bool{},
+		length:   map[*x509.Certificate]int{},
+		visiting: map[*x509.Certificate]bool{},
+	}
+	for _, c := range pool {
+		for _, cand := range g.issuersOf(c) {
+			g.issued[cand] = true
+		}
+	}
+	return g
+}
+
+// issuersOf returns the certificates of the pool that signed c, which may
+// also be one fetched from outside it.
+func (g *issuerGraph) issuersOf(c *x509.Certificate) []*x509.Certificate {
+	if issuers, ok := g.issuers[c]; ok {
+		return issuers
+	}
+	var issuers []*x509.Certificate
+	for _, cand := range g.pool {
+		if signedBy(c, cand) {
+			issuers = append(issuers, cand)
+		}
+	}
+	g.issuers[c] = issuers
+	return issuers
+}
+
+// chooseLeaf picks the certificate of the pool that issued none of the
+// others.
+func (g *issuerGraph) chooseLeaf() *x509.Certificate {
+	var best *x509.Certificate
+	bestLen := 0
+	for _, c := range g.pool {
+		if g.issued[c] {
+			continue
+		}
+		n := 1 + g.pathLength(c)
+		switch {
+		case best == nil,
+			best.IsCA && !c.IsCA,
+			best.IsCA == c.IsCA && n > bestLen:
+			best, bestLen = c, n
+		}
+	}
+	if best == nil {
+		// Every certificate issued another, as in a pair of CAs that
+		// cross-signed each other.
+		return g.pool[0]
+	}
+	return best
+}
+
+// longestIssuer returns the issuer of c, outside path, that leads to the
+// longest chain, or nil.
+func (g *issuerGraph) longestIssuer(c *x509.Certificate, path []*x509.Certificate) *x509.Certificate {
+	var best *x509.Certificate
+	bestLen := -1
+	for _, cand := range g.issuersOf(c) {
+		if containsCert(path, cand) {
+			continue
+		}
+		if n := g.pathLength(cand); n > bestLen {
+			best, bestLen = cand, n
+		}
+	}
+	return best
+}
+
+// pathLength is the number of certificates above c in the longest chain
+// the pool can build from it, capped at maxChainDepth. A cycle of
+// cross-signed CAs is cut where the search first meets it, so there the
+// length is a lower bound rather than exact.
+func (g *issuerGraph) pathLength(c *x509.Certificate) int {
+	if n, ok := g.length[c]; ok {
+		return n
+	}
+	if isSelfSigned(c) || g.visiting[c] {
+		return 0
+	}
+	g.visiting[c] = true
+	longest := 0
+	for _, cand := range g.issuersOf(c) {
+		if n := 1 + g.pathLength(cand); n > longest {
+			longest = min(n, maxChainDepth)
+		}
+	}
+	delete(g.visiting, c)
+	g.length[c] = longest
+	return longest
+}
+
+// trustAnchor returns the root among roots, or in the system trust store
+// when roots is empty, that issued c, or nil.
+func trustAnchor(c *x509.Certificate, roots []*x509.Certificate) *x509.Certificate {
+	if len(roots) > 0 {
+		return FindIssuer(c, roots)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		return nil
+	}
+	// The system pool cannot be listed, so let Verify find the root. It
+	// is asked about the start of c's validity so that ordering an
+	// expired bundle still works.
+	chains, err := c.Verify(x509.VerifyOptions{
+		Roots:       pool,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		CurrentTime: c.NotBefore,
+	})
+	if err != nil || len(chains) == 0 || len(chains[0]) < 2 {
+		return nil
+	}
+	return chains[0][len(chains[0])-1]
+}
+
+// fetchIssuer downloads c's issuer from its AIA caIssuers URLs, recording
+// failures in res.Errors. It returns the issuer and the URL it came from.
+func fetchIssuer(c *x509.Certificate, timeout time.Duration, res *ChainResult) (*x509.Certificate, string) {
+	for _, u := range c.IssuingCertificateURL {
+		certs, err := FetchAIA(u, timeout)
+		if err != nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("%s: %v", u, err))
+			continue
+		}
+		for _, cand := range certs {
+			if signedBy(c, cand) {
+				return cand, u
+			}
+		}
+		res.Errors = append(res.Errors, fmt.Sprintf("%s: no issuer of %q", u, FormatName(c.Subject)))
+	}
+	return nil, ""
+}
+
+// FetchAIA downloads the certificates at an AIA caIssuers URL, which
+// usually serves a single DER certificate or a PKCS#7 bundle.
+func FetchAIA(rawURL string, timeout time.Duration) ([]*x509.Certificate, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAIAResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	return ParseCertificates(data)
+}
+
+// Text renders res in the certinfo text layout.
+func (res *ChainResult) Text() string {
+	var buf strings.Builder
+
+	switch {
+	case res.RootOmitted:
+		fmt.Fprintf(&buf, "Chain:               complete (%d certificates, root omitted)\n", len(res.Entries))
+	case res.Complete:
+		fmt.Fprintf(&buf, "Chain:               complete (%d certificates)\n", len(res.Entries))
+	default:
+		fmt.Fprintf(&buf, "Chain:               INCOMPLETE (%d certificates)\n", len(res.Entries))
+	}
+	for i, e := range res.Entries {
+		name := e.Subject
+		if e.SelfSigned {
+			name += " (self-signed)"
+		}
+		fmt.Fprintf(&buf, "  %d: %s\n", i, name)
+		if e.FetchedFrom != "" {
+			fmt.Fprintf(&buf, "       fetched from %s\n", e.FetchedFrom)
+		} else {
+			fmt.Fprintf(&buf, "       input #%d\n", e.Index)
+		}
+	}
+	if res.Anchor != "" {
+		fmt.Fprintf(&buf, "Trusted Root:        %s (not in the chain)\n", res.Anchor)
+	}
+	if res.Missing != nil {
+		fmt.Fprintf(&buf, "Missing Issuer:      %s\n", res.Missing.Subject)
+		if res.Missing.AuthorityKeyID != "" {
+			fmt.Fprintf(&buf, "  Authority Key ID:  %s\n", res.Missing.AuthorityKeyID)
+		}
+		if len(res.Missing.URLs) > 0 {
+			fmt.Fprintf(&buf, "  AIA Issuer URL:    %s\n", strings.Join(res.Missing.URLs, ", "))
+		}
+	}
+	fmt.Fprintf(&buf, "Reordered:           %t\n", res.Reordered)
+	if res.Duplicates > 0 {
+		fmt.Fprintf(&buf, "Duplicates:          %d removed\n", res.Duplicates)
+	}
+	if len(res.Extraneous) > 0 {
+		fmt.Fprintf(&buf, "Extraneous:          %d not part of the chain\n", len(res.Extraneous))
+		for _, e := range res.Extraneous {
+			fmt.Fprintf(&buf, "  input #%d: %s\n", e.Index, e.Subject)
+		}
+	}
+	for _, e := range res.Errors {
+		fmt.Fprintf(&buf, "AIA Error:           %s\n", e)
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,221 @@
+package pki
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBuildChainOrders tests ordering a shuffled bundle with a duplicate
+// and an unrelated certificate
+func TestBuildChainOrders(t *testing.T) {
+	root := newTestRoot(t, "Chain Root")
+	inter := root.intermediate(t, "Chain Intermediate")
+	leaf := inter.leaf(t, "chain.example.com")
+	other := newTestRoot(t, "Other Root")
+
+	res, err := BuildChain([]*x509.Certificate{root.cert, other.cert, leaf, inter.cert, leaf}, ChainOptions{})
+	if err != nil {
+		t.Fatalf("BuildChain failed: %v", err)
+	}
+	want := []*x509.Certificate{leaf, inter.cert, root.cert}
+	if len(res.Chain) != len(want) {
+		t.Fatalf("Expected %d certificates, got %d", len(want), len(res.Chain))
+	}
+	for i, c := range want {
+		if !res.Chain[i].Equal(c) {
+			t.Errorf("Expected %s at position %d, got %s", c.Subject, i, res.Chain[i].Subject)
+		}
+	}
+	if !res.Complete || !res.Reordered || res.Duplicates != 1 {
+		t.Errorf("Expected complete, reordered, 1 duplicate; got %t, %t, %d", res.Complete, res.Reordered, res.Duplicates)
+	}
+	if len(res.Extraneous) != 1 || res.Extraneous[0].Index != 2 {
+		t.Errorf("Expected input #2 to be extraneous, got %+v", res.Extraneous)
+	}
+	if res.Entries[0].Index != 3 || !res.Entries[2].SelfSigned {
+		t.Errorf("Expected entries to record input positions, got %+v", res.Entries)
+	}
+}
+
+// TestBuildChainMissing tests reporting a missing intermediate
+func TestBuildChainMissing(t *testing.T) {
+	root := newTestRoot(t, "Chain Root")
+	inter := root.intermediate(t, "Chain Intermediate")
+	leaf := inter.leaf(t, "chain.example.com")
+
+	res, err := BuildChain([]*x509.Certificate{leaf, root.cert}, ChainOptions{})
+	if err != nil {
+		t.Fatalf("BuildChain failed: %v", err)
+	}
+	if res.Complete || len(res.Chain) != 1 {
+		t.Errorf("Expected an incomplete chain of the leaf alone, got %d certificates", len(res.Chain))
+	}
+	if res.Missing == nil || res.Missing.Subject != "CN=Chain Intermediate" {
+		t.Errorf("Expected the intermediate to be missing, got %+v", res.Missing)
+	}
+	if len(res.Extraneous) != 1 || !strings.Contains(res.Text(), "Missing Issuer:      CN=Chain Intermediate") {
+		t.Errorf("Expected the root to be extraneous and the text to name the missing issuer:\n%s", res.Text())
+	}
+}
+
+// TestBuildChainRootOmitted tests that a server bundle without its root is
+// complete when the root is trusted
+func TestBuildChainRootOmitted(t *testing.T) {
+	root := newTestRoot(t, "Chain Root")
+	inter := root.intermediate(t, "Chain Intermediate")
+	leaf := inter.leaf(t, "chain.example.com")
+
+	res, err := BuildChain([]*x509.Certificate{inter.cert, leaf}, ChainOptions{Roots: []*x509.Certificate{root.cert}})
+	if err != nil {
+		t.Fatalf("BuildChain failed: %v", err)
+	}
+	if !res.Complete || !res.RootOmitted || res.Missing != nil {
+		t.Errorf("Expected a complete chain with the root omitted, got complete=%t rootOmitted=%t missing=%+v", res.Complete, res.RootOmitted, res.Missing)
+	}
+	if len(res.Chain) != 2 || res.Anchor != "CN=Chain Root" {
+		t.Errorf("Expected 2 certificates below CN=Chain Root, got %d below %q", len(res.Chain), res.Anchor)
+	}
+	if text := res.Text(); !strings.Contains(text, "root omitted") || !strings.Contains(text, "Trusted Root:        CN=Chain Root") {
+		t.Errorf("Expected the text to report the omitted root, got:\n%s", text)
+	}
+
+	// An untrusted root is still missing
+	other := newTestRoot(t, "Other Root")
+	res, err = BuildChain([]*x509.Certificate{inter.cert, leaf}, ChainOptions{Roots: []*x509.Certificate{other.cert}})
+	if err != nil {
+		t.Fatalf("BuildChain failed: %v", err)
+	}
+	if res.Complete || res.RootOmitted || res.Missing == nil || res.Missing.Subject != "CN=Chain Root" {
+		t.Errorf("Expected CN=Chain Root to be missing, got complete=%t missing=%+v", res.Complete, res.Missing)
+	}
+}
+
+// TestBuildChainFetchAIA tests completing a chain from the caIssuers URL
+func TestBuildChainFetchAIA(t *testing.T) {
+	root := newTestRoot(t, "Chain Root")
+	inter := root.intermediate(t, "Chain Intermediate")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/inter.cer" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(inter.cert.Raw)
+	}))
+	defer ts.Close()
+
+	leaf, _ := inter.issue(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "aia.example.com"},
+		IssuingCertificateURL: []string{ts.URL + "/missing.cer", ts.URL + "/inter.cer"},
+	})
+
+	res, err := BuildChain([]*x509.Certificate{leaf, root.cert}, ChainOptions{FetchAIA: true})
+	if err != nil {
+		t.Fatalf("BuildChain failed: %v", err)
+	}
+	if !res.Complete || len(res.Chain) != 3 {
+		t.Fatalf("Expected a complete chain of 3, got %d (missing %+v)", len(res.Chain), res.Missing)
+	}
+	if res.Entries[1].FetchedFrom != ts.URL+"/inter.cer" || res.Entries[1].Index != 0 {
+		t.Errorf("Expected the intermediate to be fetched, got %+v", res.Entries[1])
+	}
+	if res.Reordered {
+		t.Error("Expected input already in order not to be flagged as reordered")
+	}
+	if len(res.Errors) != 1 || !strings.Contains(res.Errors[0], "404") {
+		t.Errorf("Expected the failed URL to be reported, got %v", res.Errors)
+	}
+}
+
+// TestBuildChainCrossSigned tests preferring the longer path through a
+// cross-signed intermediate
+func TestBuildChainCrossSigned(t *testing.T) {
+	oldRoot := newTestRoot(t, "Old Root")
+	newRoot := newTestRoot(t, "New Root")
+	// The new root's key, certified by the old root.
+	crossCert := crossSign(t, newRoot, oldRoot)
+	leaf := newRoot.leaf(t, "cross.example.com")
+
+	res, err := BuildChain([]*x509.Certificate{newRoot.cert, leaf, oldRoot.cert, crossCert}, ChainOptions{})
+	if err != nil {
+		t.Fatalf("BuildChain failed: %v", err)
+	}
+	want := []*x509.Certificate{leaf, crossCert, oldRoot.cert}
+	if len(res.Chain) != len(want) {
+		t.Fatalf("Expected %d certificates, got %d", len(want), len(res.Chain))
+	}
+	for i, c := range want {
+		if !res.Chain[i].Equal(c) {
+			t.Errorf("Expected %s at position %d, got %s", c.Subject, i, res.Chain[i].Subject)
+		}
+	}
+	if len(res.Extraneous) != 1 || res.Extraneous[0].Subject != "CN=New Root" {
+		t.Errorf("Expected the self-signed new root to be extraneous, got %+v", res.Extraneous)
+	}
+}
+
+// TestBuildChainCrossSignedMesh tests that CAs which all cross-signed each
+// other do not make the search blow up
+func TestBuildChainCrossSignedMesh(t *testing.T) {
+	var cas []*testIssuer
+	for i := range 5 {
+		cas = append(cas, newTestRoot(t, fmt.Sprintf("Mesh CA %d", i)))
+	}
+	leaf := cas[0].leaf(t, "mesh.example.com")
+	certs := []*x509.Certificate{leaf}
+	for _, subject := range cas {
+		for _, issuer := range cas {
+			if subject != issuer {
+				certs = append(certs, crossSign(t, subject, issuer))
+			}
+		}
+	}
+
+	start := time.Now()
+	res, err := BuildChain(certs, ChainOptions{})
+	if err != nil {
+		t.Fatalf("BuildChain failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected BuildChain to finish quickly, took %s", elapsed)
+	}
+	if !res.Chain[0].Equal(leaf) {
+		t.Errorf("Expected the leaf first, got %s", res.Chain[0].Subject)
+	}
+	if len(res.Chain) < 2 || len(res.Chain) > maxChainDepth {
+		t.Errorf("Expected a chain of 2 to %d certificates, got %d", maxChainDepth, len(res.Chain))
+	}
+	if len(res.Chain)+len(res.Extraneous) != len(certs) {
+		t.Errorf("Expected every certificate in the chain or extraneous, got %d and %d of %d", len(res.Chain), len(res.Extraneous), len(certs))
+	}
+}
+
+// crossSign certifies subject's name and key with issuer, as a CA does for
+// a new root
+func crossSign(t *testing.T, subject, issuer *testIssuer) *x509.Certificate {
+	t.Helper()
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:          subject.cert.SerialNumber,
+		Subject:               subject.cert.Subject,
+		NotBefore:             subject.cert.NotBefore,
+		NotAfter:              subject.cert.NotAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SubjectKeyId:          subject.cert.SubjectKeyId,
+	}, issuer.cert, subject.key.Public(), issuer.key)
+	if err != nil {
+		t.Fatalf("Failed to cross-sign: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse cross-signed certificate: %v", err)
+	}
+	return cert
+}
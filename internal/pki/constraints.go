@@ -0,0 +1,351 @@
+package pki
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+const oidNameConstraints = "2.5.29.30"
+
+// CAConstraints lists the name constraints of one CA in a chain, rendered
+// like the extension ("DNS:example.com", "IP:10.0.0.0/8", ...).
+type CAConstraints struct {
+	Subject   string   `json:"subject" yaml:"subject"`
+	Permitted []string `json:"permitted,omitempty" yaml:"permitted,omitempty"`
+	Excluded  []string `json:"excluded,omitempty" yaml:"excluded,omitempty"`
+	// NotEvaluated lists constraints on name types other than DNS, IP,
+	// email and URI, such as directoryName, which are shown but not
+	// checked.
+	NotEvaluated []string `json:"not_evaluated,omitempty" yaml:"not_evaluated,omitempty"`
+}
+
+// SANVerdict is the outcome for one subject alternative name.
+type SANVerdict struct {
+	SAN        string   `json:"san" yaml:"san"`
+	Allowed    bool     `json:"allowed" yaml:"allowed"`
+	Violations []string `json:"violations,omitempty" yaml:"violations,omitempty"`
+}
+
+// ConstraintsResult reports EvaluateNameConstraints.
+type ConstraintsResult struct {
+	Subject string `json:"subject" yaml:"subject"`
+	// Issuers are the CAs above the certificate, nearest first.
+	Issuers []CAConstraints `json:"issuers" yaml:"issuers"`
+	// Complete is set when the issuers reach a self-signed root; otherwise
+	// constraints of CAs that were not supplied are unknown.
+	Complete bool         `json:"complete" yaml:"complete"`
+	SANs     []SANVerdict `json:"sans" yaml:"sans"`
+}
+
+// OK reports whether every SAN was allowed.
+func (r *ConstraintsResult) OK() bool {
+	for _, s := range r.SANs {
+		if !s.Allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// EvaluateNameConstraints follows leaf's issuers through chain and checks
+// every DNS, IP, email and URI SAN of leaf against the name constraints of
+// each of them. The subtrees accumulate as in RFC 5280, section 6.1: a name
+// must fall within the permitted subtrees of every CA that has some for its
+// type, and within none of the excluded subtrees of any CA.
+func EvaluateNameConstraints(leaf *x509.Certificate, chain []*x509.Certificate) *ConstraintsResult {
+	issuers := issuerChain(leaf, chain)
+	res := &ConstraintsResult{
+		Subject:  FormatName(leaf.Subject),
+		Complete: isSelfSigned(leaf) || len(issuers) > 0 && isSelfSigned(issuers[len(issuers)-1]),
+	}
+	for _, c := range issuers {
+		res.Issuers = append(res.Issuers, caConstraints(c))
+	}
+
+	var sans []string
+	for _, name := range leaf.DNSNames {
+		sans = append(sans, "DNS:"+name)
+	}
+	for _, ip := range leaf.IPAddresses {
+		sans = append(sans, "IP:"+ip.String())
+	}
+	for _, email := range leaf.EmailAddresses {
+		sans = append(sans, "email:"+email)
+	}
+	for _, uri := range leaf.URIs {
+		sans = append(sans, "URI:"+uri.String())
+	}
+	for _, san := range sans {
+		v := SANVerdict{SAN: san, Violations: sanViolations(issuers, san)}
+		v.Allowed = len(v.Violations) == 0
+		res.SANs = append(res.SANs, v)
+	}
+	return res
+}
+
+// caConstraints renders the name constraints extension of c.
+func caConstraints(c *x509.Certificate) CAConstraints {
+	out := CAConstraints{Subject: FormatName(c.Subject)}
+	for _, e := range c.Extensions {
+		if e.Id.String() != oidNameConstraints {
+			continue
+		}
+		lines, err := decodeNameConstraints(e.Value)
+		if err != nil {
+			out.NotEvaluated = append(out.NotEvaluated, "malformed: "+err.Error())
+			continue
+		}
+		for _, line := range lines {
+			label, name, _ := strings.Cut(line, ": ")
+			kind, _, _ := strings.Cut(name, ":")
+			switch {
+			case kind != "DNS" && kind != "IP" && kind != "email" && kind != "URI":
+				out.NotEvaluated = append(out.NotEvaluated, line)
+			case label == "Permitted":
+				out.Permitted = append(out.Permitted, name)
+			default:
+				out.Excluded = append(out.Excluded, name)
+			}
+		}
+	}
+	return out
+}
+
+// issuerChain follows leaf's issuers through candidates, nearest first,
+// until it reaches a self-signed certificate or finds no issuer.
+func issuerChain(leaf *x509.Certificate, candidates []*x509.Certificate) []*x509.Certificate {
+	var chain []*x509.Certificate
+	cur := leaf
+	for len(chain) < maxChainDepth && !isSelfSigned(cur) {
+		issuer := FindIssuer(cur, candidates)
+		if issuer == nil || issuer.Equal(leaf) || containsCert(chain, issuer) {
+			break
+		}
+		chain = append(chain, issuer)
+		cur = issuer
+	}
+	return chain
+}
+
+// sanViolations checks a name given as "DNS:...", "IP:...", "email:..." or
+// "URI:..." against the name constraints of every certificate in issuers
+// and describes each one it breaks.
+func sanViolations(issuers []*x509.Certificate, san string) []string {
+	kind, value, _ := strings.Cut(san, ":")
+	var out []string
+	for _, c := range issuers {
+		subject := FormatName(c.Subject)
+		switch kind {
+		case "DNS":
+			name := strings.TrimSuffix(strings.ToLower(value), ".")
+			out = append(out, subtreeViolations(subject, kind, c.PermittedDNSDomains, c.ExcludedDNSDomains, func(constraint string) bool {
+				return dnsConstraintMatches(name, constraint)
+			})...)
+			for _, e := range c.ExcludedDNSDomains {
+				if !dnsConstraintMatches(name, e) && wildcardCovers(name, e) {
+					out = append(out, "the wildcard covers names excluded by "+subject+": DNS:"+e)
+				}
+			}
+		case "IP":
+			ip := net.ParseIP(value)
+			if ip == nil {
+				out = append(out, "invalid IP address "+value)
+				continue
+			}
+			permitted, excluded := ipNetStrings(c.PermittedIPRanges), ipNetStrings(c.ExcludedIPRanges)
+			ranges := map[string]*net.IPNet{}
+			for i, s := range permitted {
+				ranges[s] = c.PermittedIPRanges[i]
+			}
+			for i, s := range excluded {
+				ranges[s] = c.ExcludedIPRanges[i]
+			}
+			out = append(out, subtreeViolations(subject, kind, permitted, excluded, func(constraint string) bool {
+				return ipConstraintMatches(ip, ranges[constraint])
+			})...)
+		case "email":
+			out = append(out, subtreeViolations(subject, kind, c.PermittedEmailAddresses, c.ExcludedEmailAddresses, func(constraint string) bool {
+				return emailConstraintMatches(value, constraint)
+			})...)
+		case "URI":
+			if len(c.PermittedURIDomains) == 0 && len(c.ExcludedURIDomains) == 0 {
+				continue
+			}
+			host, err := uriHost(value)
+			if err != nil {
+				out = append(out, "not checkable against the URI constraints of "+subject+": "+err.Error())
+				continue
+			}
+			out = append(out, subtreeViolations(subject, kind, c.PermittedURIDomains, c.ExcludedURIDomains, func(constraint string) bool {
+				return uriConstraintMatches(host, constraint)
+			})...)
+		}
+	}
+	return out
+}
+
+// subtreeViolations applies one CA's permitted and excluded subtrees of a
+// single name type, using matches to test the name against each.
+func subtreeViolations(subject, kind string, permitted, excluded []string, matches func(constraint string) bool) []string {
+	var out []string
+	for _, e := range excluded {
+		if matches(e) {
+			out = append(out, "excluded by "+subject+": "+kind+":"+e)
+		}
+	}
+	if len(permitted) == 0 {
+		return out
+	}
+	for _, p := range permitted {
+		if matches(p) {
+			return out
+		}
+	}
+	return append(out, "not permitted by "+subject+": "+kind+":"+strings.Join(permitted, ", "+kind+":"))
+}
+
+// dnsConstraintMatches reports whether name falls within a dNSName
+// constraint (RFC 5280, section 4.2.1.10): "example.com" covers the domain
+// and its subdomains, ".example.com" only the subdomains, and an empty
+// constraint every name. name must already be lower case.
+func dnsConstraintMatches(name, constraint string) bool {
+	constraint = strings.TrimSuffix(strings.ToLower(constraint), ".")
+	switch {
+	case constraint == "":
+		return true
+	case strings.HasPrefix(constraint, "."):
+		return strings.HasSuffix(name, constraint)
+	}
+	return name == constraint || strings.HasSuffix(name, "."+constraint)
+}
+
+// wildcardCovers reports whether a wildcard DNS name such as
+// "*.example.com" stands for a host the dNSName constraint covers, as
+// "*.example.com" does for "bad.example.com", even though the literal name
+// does not fall within it.
+func wildcardCovers(name, constraint string) bool {
+	suffix, ok := strings.CutPrefix(name, "*.")
+	constraint = strings.TrimSuffix(strings.ToLower(constraint), ".")
+	if !ok || strings.HasPrefix(constraint, ".") {
+		return false
+	}
+	label, ok := strings.CutSuffix(constraint, "."+suffix)
+	return ok && label != "" && !strings.Contains(label, ".")
+}
+
+// ipConstraintMatches reports whether ip falls within an iPAddress
+// constraint. IPv4 addresses never match IPv6 ranges or the reverse.
+func ipConstraintMatches(ip net.IP, constraint *net.IPNet) bool {
+	if (ip.To4() != nil) != (len(constraint.IP) == net.IPv4len) {
+		return false
+	}
+	return constraint.Contains(ip)
+}
+
+// emailConstraintMatches reports whether a mailbox falls within an
+// rfc822Name constraint: "user@example.com" is that mailbox,
+// "example.com" every mailbox at the host, and ".example.com" every
+// mailbox in its subdomains. Local parts compare case-sensitively.
+func emailConstraintMatches(mailbox, constraint string) bool {
+	at := strings.LastIndexByte(mailbox, '@')
+	if at < 0 {
+		return false
+	}
+	local, domain := mailbox[:at], strings.ToLower(mailbox[at+1:])
+	if cLocal, cDomain, ok := strings.Cut(constraint, "@"); ok {
+		return local == cLocal && domain == strings.ToLower(cDomain)
+	}
+	constraint = strings.ToLower(constraint)
+	if strings.HasPrefix(constraint, ".") {
+		return strings.HasSuffix(domain, constraint)
+	}
+	return domain == constraint
+}
+
+// uriConstraintMatches reports whether a URI's host falls within a
+// uniformResourceIdentifier constraint: ".example.com" covers the hosts in
+// the domain and "host.example.com" only that host (RFC 5280, section
+// 4.2.1.10). host must be lower case.
+func uriConstraintMatches(host, constraint string) bool {
+	constraint = strings.ToLower(constraint)
+	if strings.HasPrefix(constraint, ".") {
+		return strings.HasSuffix(host, constraint)
+	}
+	return host == constraint
+}
+
+// uriHost extracts the host URI constraints apply to.
+func uriHost(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	switch {
+	case host == "":
+		return "", fmt.Errorf("%s has no host", uri)
+	case net.ParseIP(host) != nil:
+		return "", fmt.Errorf("the host of %s is an IP address", uri)
+	}
+	return host, nil
+}
+
+func ipNetStrings(nets []*net.IPNet) []string {
+	out := make([]string, len(nets))
+	for i, n := range nets {
+		out[i] = n.String()
+	}
+	return out
+}
+
+// Text renders r with the constraints of each issuer and a verdict per SAN.
+func (r *ConstraintsResult) Text() string {
+	var buf strings.Builder
+
+	fmt.Fprintf(&buf, "Certificate:         %s\n", r.Subject)
+	if r.Complete {
+		fmt.Fprintf(&buf, "Issuers:             %d, up to a self-signed root\n", len(r.Issuers))
+	} else {
+		fmt.Fprintf(&buf, "Issuers:             %d, INCOMPLETE (constraints of missing CAs are not checked)\n", len(r.Issuers))
+	}
+	for _, ca := range r.Issuers {
+		fmt.Fprintf(&buf, "  %s\n", ca.Subject)
+		if len(ca.Permitted) == 0 && len(ca.Excluded) == 0 && len(ca.NotEvaluated) == 0 {
+			fmt.Fprintf(&buf, "    Constraints:     none\n")
+		}
+		if len(ca.Permitted) > 0 {
+			fmt.Fprintf(&buf, "    Permitted:       %s\n", strings.Join(ca.Permitted, ", "))
+		}
+		if len(ca.Excluded) > 0 {
+			fmt.Fprintf(&buf, "    Excluded:        %s\n", strings.Join(ca.Excluded, ", "))
+		}
+		if len(ca.NotEvaluated) > 0 {
+			fmt.Fprintf(&buf, "    Not Evaluated:   %s\n", strings.Join(ca.NotEvaluated, ", "))
+		}
+	}
+	if len(r.SANs) == 0 {
+		fmt.Fprintf(&buf, "SANs:                none\n")
+	}
+	violations := 0
+	for _, s := range r.SANs {
+		if s.Allowed {
+			fmt.Fprintf(&buf, "SAN:                 %s: OK\n", s.SAN)
+			continue
+		}
+		violations++
+		fmt.Fprintf(&buf, "SAN:                 %s: VIOLATION\n", s.SAN)
+		for _, v := range s.Violations {
+			fmt.Fprintf(&buf, "  %s\n", v)
+		}
+	}
+	if violations > 0 {
+		fmt.Fprintf(&buf, "Result:              FAILED: %d of %d SANs violate the name constraints\n", violations, len(r.SANs))
+	} else {
+		fmt.Fprintf(&buf, "Result:              OK\n")
+	}
+
+	return buf.String()
+}
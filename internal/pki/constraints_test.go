@@ -0,0 +1,173 @@
+package pki
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestDNSConstraintMatches tests the RFC 5280 dNSName constraint forms
+func TestDNSConstraintMatches(t *testing.T) {
+	tests := []struct {
+		name, constraint string
+		match            bool
+	}{
+		{"example.com", "example.com", true},
+		{"www.example.com", "Example.com", true},
+		{"wwwexample.com", "example.com", false},
+		{"example.com", ".example.com", false},
+		{"www.example.com", ".example.com", true},
+		{"anything.test", "", true},
+	}
+	for _, tt := range tests {
+		if got := dnsConstraintMatches(tt.name, tt.constraint); got != tt.match {
+			t.Errorf("Expected %q within %q to be %t", tt.name, tt.constraint, tt.match)
+		}
+	}
+}
+
+// TestIPConstraintMatches tests that address families do not cross
+func TestIPConstraintMatches(t *testing.T) {
+	_, v4, _ := net.ParseCIDR("10.0.0.0/8")
+	_, v6, _ := net.ParseCIDR("::/0")
+	if !ipConstraintMatches(net.ParseIP("10.1.2.3"), v4) {
+		t.Error("Expected 10.1.2.3 to be within 10.0.0.0/8")
+	}
+	if ipConstraintMatches(net.ParseIP("10.1.2.3"), v6) {
+		t.Error("Expected an IPv4 address not to match an IPv6 range")
+	}
+	if ipConstraintMatches(net.ParseIP("2001:db8::1"), v4) {
+		t.Error("Expected an IPv6 address not to match an IPv4 range")
+	}
+}
+
+// TestEmailConstraintMatches tests mailbox, host and domain constraints
+func TestEmailConstraintMatches(t *testing.T) {
+	tests := []struct {
+		mailbox, constraint string
+		match               bool
+	}{
+		{"alice@example.com", "alice@EXAMPLE.com", true},
+		{"Alice@example.com", "alice@example.com", false},
+		{"alice@Example.com", "example.com", true},
+		{"alice@mail.example.com", "example.com", false},
+		{"alice@mail.example.com", ".example.com", true},
+		{"alice@example.com", ".example.com", false},
+	}
+	for _, tt := range tests {
+		if got := emailConstraintMatches(tt.mailbox, tt.constraint); got != tt.match {
+			t.Errorf("Expected %q within %q to be %t", tt.mailbox, tt.constraint, tt.match)
+		}
+	}
+}
+
+// TestURIConstraintMatches tests host and domain constraints on URI hosts
+func TestURIConstraintMatches(t *testing.T) {
+	host, err := uriHost("https://SVC.example.com:8443/path")
+	if err != nil || host != "svc.example.com" {
+		t.Fatalf("Expected the lower-cased host, got %q (%v)", host, err)
+	}
+	if !uriConstraintMatches(host, ".example.com") || !uriConstraintMatches(host, "svc.example.com") {
+		t.Error("Expected the host to be within .example.com and svc.example.com")
+	}
+	if uriConstraintMatches(host, "example.com") {
+		t.Error("Expected a constraint without a leading period to name a single host")
+	}
+	for _, uri := range []string{"urn:uuid:1234", "https://10.0.0.1/"} {
+		if _, err := uriHost(uri); err == nil {
+			t.Errorf("Expected %s to have no checkable host", uri)
+		}
+	}
+}
+
+// TestEvaluateNameConstraints tests accumulating the constraints of two
+// intermediates and giving a verdict per SAN
+func TestEvaluateNameConstraints(t *testing.T) {
+	root := newTestRoot(t, "Constraint Root")
+	outerCert, outerKey := root.issue(t, &x509.Certificate{
+		Subject:                 pkix.Name{CommonName: "Outer CA"},
+		KeyUsage:                x509.KeyUsageCertSign,
+		BasicConstraintsValid:   true,
+		IsCA:                    true,
+		ExcludedDNSDomains:      []string{"bad.example.com"},
+		PermittedEmailAddresses: []string{"example.com"},
+	})
+	outer := &testIssuer{cert: outerCert, key: outerKey}
+	_, tenNet, _ := net.ParseCIDR("10.0.0.0/8")
+	innerCert, innerKey := outer.issue(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Inner CA"},
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		PermittedDNSDomains:   []string{"example.com"},
+		PermittedIPRanges:     []*net.IPNet{tenNet},
+		PermittedURIDomains:   []string{".example.com"},
+	})
+	inner := &testIssuer{cert: innerCert, key: innerKey}
+	good, _ := url.Parse("https://svc.example.com/")
+	bad, _ := url.Parse("spiffe://other.org/workload")
+	leaf, _ := inner.issue(t, &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "constrained"},
+		DNSNames:       []string{"www.example.com", "*.example.com", "evil.org"},
+		IPAddresses:    []net.IP{net.ParseIP("10.1.2.3"), net.ParseIP("192.168.0.1")},
+		EmailAddresses: []string{"ops@example.com", "ops@sub.example.com"},
+		URIs:           []*url.URL{good, bad},
+	})
+
+	res := EvaluateNameConstraints(leaf, []*x509.Certificate{root.cert, outer.cert, inner.cert})
+	if !res.Complete || len(res.Issuers) != 3 || res.Issuers[0].Subject != "CN=Inner CA" {
+		t.Fatalf("Expected a complete chain of three issuers, got %+v", res.Issuers)
+	}
+	if got := strings.Join(res.Issuers[1].Excluded, ","); got != "DNS:bad.example.com" {
+		t.Errorf("Expected the outer CA's exclusion to be listed, got %q", got)
+	}
+	want := map[string]string{
+		"DNS:www.example.com":             "",
+		"DNS:*.example.com":               "the wildcard covers names excluded by CN=Outer CA",
+		"DNS:evil.org":                    "not permitted by CN=Inner CA: DNS:example.com",
+		"IP:10.1.2.3":                     "",
+		"IP:192.168.0.1":                  "not permitted by CN=Inner CA: IP:10.0.0.0/8",
+		"email:ops@example.com":           "",
+		"email:ops@sub.example.com":       "not permitted by CN=Outer CA: email:example.com",
+		"URI:https://svc.example.com/":    "",
+		"URI:spiffe://other.org/workload": "not permitted by CN=Inner CA: URI:.example.com",
+	}
+	if len(res.SANs) != len(want) {
+		t.Fatalf("Expected %d SANs, got %d", len(want), len(res.SANs))
+	}
+	for _, v := range res.SANs {
+		violation, ok := want[v.SAN]
+		if !ok {
+			t.Errorf("Unexpected SAN %s", v.SAN)
+			continue
+		}
+		if v.Allowed != (violation == "") {
+			t.Errorf("Expected %s allowed=%t, got %+v", v.SAN, violation == "", v)
+		}
+		if violation != "" && (len(v.Violations) != 1 || !strings.Contains(v.Violations[0], violation)) {
+			t.Errorf("Expected %s to violate '%s', got %v", v.SAN, violation, v.Violations)
+		}
+	}
+	if res.OK() || !strings.Contains(res.Text(), "Result:              FAILED: 5 of 9 SANs violate the name constraints") {
+		t.Errorf("Expected the text to report 5 violations:\n%s", res.Text())
+	}
+}
+
+// TestEvaluateNameConstraintsIncomplete tests that a missing issuer is
+// reported rather than taken as unconstrained
+func TestEvaluateNameConstraintsIncomplete(t *testing.T) {
+	root := newTestRoot(t, "Constraint Root")
+	inter := root.intermediate(t, "Unconstrained CA")
+	leaf := inter.leaf(t, "www.example.com")
+
+	res := EvaluateNameConstraints(leaf, nil)
+	if res.Complete || len(res.Issuers) != 0 || !res.OK() {
+		t.Errorf("Expected an incomplete chain with nothing violated, got %+v", res)
+	}
+	if !strings.Contains(res.Text(), "INCOMPLETE") {
+		t.Errorf("Expected the text to flag the incomplete chain:\n%s", res.Text())
+	}
+}
@@ -0,0 +1,104 @@
+package pki
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// createTestCRL has root revoke the given serial with keyCompromise
+func createTestCRL(t *testing.T, root *testIssuer, serial *big.Int) []byte {
+	t.Helper()
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(3),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: serial, RevocationTime: time.Now().Add(-time.Minute), ReasonCode: 1},
+		},
+	}, root.cert, root.key)
+	if err != nil {
+		t.Fatalf("Failed to create CRL: %v", err)
+	}
+	return der
+}
+
+// TestParseCRL tests PEM and DER CRL parsing and the structured view
+func TestParseCRL(t *testing.T) {
+	root := newTestRoot(t, "CRL Root")
+	der := createTestCRL(t, root, big.NewInt(0x1234))
+
+	for name, data := range map[string][]byte{
+		"DER": der,
+		"PEM": pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}),
+	} {
+		crl, err := ParseCRL(data)
+		if err != nil {
+			t.Fatalf("%s: ParseCRL failed: %v", name, err)
+		}
+		ci := GetCRLInfo(crl)
+		if ci.Number != "3" {
+			t.Errorf("%s: Expected CRL number 3, got %s", name, ci.Number)
+		}
+		if len(ci.Revoked) != 1 || ci.Revoked[0].Serial != "12:34" || ci.Revoked[0].Reason != "keyCompromise" {
+			t.Errorf("%s: Unexpected revoked entries %+v", name, ci.Revoked)
+		}
+		if !strings.Contains(ci.Text(), "12:34") {
+			t.Errorf("%s: Expected text to list the revoked serial", name)
+		}
+	}
+}
+
+// TestVerifyCRL tests the CRL signature check
+func TestVerifyCRL(t *testing.T) {
+	root := newTestRoot(t, "CRL Root")
+	other := newTestRoot(t, "Other Root")
+	crl, err := x509.ParseRevocationList(createTestCRL(t, root, big.NewInt(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyCRL(crl, root.cert); err != nil {
+		t.Errorf("Expected CRL to verify against its issuer, got %v", err)
+	}
+	if err := VerifyCRL(crl, other.cert); err == nil {
+		t.Error("Expected CRL verification against another root to fail")
+	}
+
+	// Same name, different key
+	impostor := newTestRoot(t, "CRL Root")
+	if err := VerifyCRL(crl, impostor.cert); err == nil {
+		t.Error("Expected CRL verification against a different key to fail")
+	}
+}
+
+// TestCheckRevocation tests revocation lookups
+func TestCheckRevocation(t *testing.T) {
+	root := newTestRoot(t, "CRL Root")
+	revoked, _ := root.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "revoked"}})
+	good, _ := root.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "good"}})
+	crl, err := x509.ParseRevocationList(createTestCRL(t, root, revoked.SerialNumber))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := CheckRevocation(crl, revoked)
+	if err != nil || entry == nil {
+		t.Errorf("Expected certificate to be revoked, got entry=%v err=%v", entry, err)
+	}
+	entry, err = CheckRevocation(crl, good)
+	if err != nil || entry != nil {
+		t.Errorf("Expected certificate not to be revoked, got entry=%v err=%v", entry, err)
+	}
+
+	other := newTestRoot(t, "Other Root")
+	foreign, _ := other.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "foreign"}})
+	if _, err := CheckRevocation(crl, foreign); err == nil {
+		t.Error("Expected error for a certificate from another issuer")
+	}
+}
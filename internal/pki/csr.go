@@ -0,0 +1,150 @@
+package pki
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"strings"
+)
+
+var (
+	oidExtKeyUsage         = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtExtKeyUsage      = asn1.ObjectIdentifier{2, 5, 29, 37}
+	oidExtBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+)
+
+var extKeyUsageOIDs = map[string]x509.ExtKeyUsage{
+	"2.5.29.37.0":            x509.ExtKeyUsageAny,
+	"1.3.6.1.5.5.7.3.1":      x509.ExtKeyUsageServerAuth,
+	"1.3.6.1.5.5.7.3.2":      x509.ExtKeyUsageClientAuth,
+	"1.3.6.1.5.5.7.3.3":      x509.ExtKeyUsageCodeSigning,
+	"1.3.6.1.5.5.7.3.4":      x509.ExtKeyUsageEmailProtection,
+	"1.3.6.1.5.5.7.3.5":      x509.ExtKeyUsageIPSECEndSystem,
+	"1.3.6.1.5.5.7.3.6":      x509.ExtKeyUsageIPSECTunnel,
+	"1.3.6.1.5.5.7.3.7":      x509.ExtKeyUsageIPSECUser,
+	"1.3.6.1.5.5.7.3.8":      x509.ExtKeyUsageTimeStamping,
+	"1.3.6.1.5.5.7.3.9":      x509.ExtKeyUsageOCSPSigning,
+	"1.3.6.1.4.1.311.10.3.3": x509.ExtKeyUsageMicrosoftServerGatedCrypto,
+	"2.16.840.1.113730.4.1":  x509.ExtKeyUsageNetscapeServerGatedCrypto,
+	"1.3.6.1.4.1.311.2.1.22": x509.ExtKeyUsageMicrosoftCommercialCodeSigning,
+	"1.3.6.1.4.1.311.61.1.1": x509.ExtKeyUsageMicrosoftKernelCodeSigning,
+}
+
+// CSRInfo is the structured view of a PKCS#10 certificate request.
+type CSRInfo struct {
+	Subject            string      `json:"subject" yaml:"subject"`
+	SignatureAlgorithm string      `json:"signature_algorithm" yaml:"signature_algorithm"`
+	PublicKey          KeyInfo     `json:"public_key" yaml:"public_key"`
+	SANs               SANs        `json:"sans" yaml:"sans"`
+	KeyUsage           []string    `json:"key_usage,omitempty" yaml:"key_usage,omitempty"`
+	ExtKeyUsage        []string    `json:"ext_key_usage,omitempty" yaml:"ext_key_usage,omitempty"`
+	IsCA               *bool       `json:"is_ca,omitempty" yaml:"is_ca,omitempty"`
+	Extensions         []Extension `json:"extensions,omitempty" yaml:"extensions,omitempty"`
+	SignatureValid     bool        `json:"signature_valid" yaml:"signature_valid"`
+	SignatureError     string      `json:"signature_error,omitempty" yaml:"signature_error,omitempty"`
+}
+
+// GetCSRInfo extracts the structured details of csr, including the
+// extensions it requests, and checks its self-signature.
+func GetCSRInfo(csr *x509.CertificateRequest) *CSRInfo {
+	ci := &CSRInfo{
+		Subject:            FormatName(csr.Subject),
+		SignatureAlgorithm: csr.SignatureAlgorithm.String(),
+		PublicKey:          publicKeyInfo(csr.PublicKey),
+		SANs:               csrSANs(csr),
+	}
+
+	for _, e := range csr.Extensions {
+		ci.Extensions = append(ci.Extensions, newExtension(e))
+		switch {
+		case e.Id.Equal(oidExtKeyUsage):
+			var bits asn1.BitString
+			if _, err := asn1.Unmarshal(e.Value, &bits); err == nil {
+				ci.KeyUsage = keyUsageToStrings(keyUsageFromBits(bits))
+			}
+		case e.Id.Equal(oidExtExtKeyUsage):
+			var oids []asn1.ObjectIdentifier
+			if _, err := asn1.Unmarshal(e.Value, &oids); err == nil {
+				ci.ExtKeyUsage = extKeyUsageOIDsToStrings(oids)
+			}
+		case e.Id.Equal(oidExtBasicConstraints):
+			var bc struct {
+				IsCA       bool `asn1:"optional"`
+				MaxPathLen int  `asn1:"optional,default:-1"`
+			}
+			if _, err := asn1.Unmarshal(e.Value, &bc); err == nil {
+				isCA := bc.IsCA
+				ci.IsCA = &isCA
+			}
+		}
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		ci.SignatureError = err.Error()
+	} else {
+		ci.SignatureValid = true
+	}
+
+	return ci
+}
+
+// Text renders ci in the same layout as CertInfo.Text.
+func (ci *CSRInfo) Text() string {
+	var buf strings.Builder
+
+	fmt.Fprintf(&buf, "Subject:             %s\n", ci.Subject)
+	fmt.Fprintf(&buf, "Signature Algorithm: %s\n", ci.SignatureAlgorithm)
+	fmt.Fprintf(&buf, "Public Key:          %s\n", ci.PublicKey.Summary)
+	if sans := ci.SANs.String(); sans != "" {
+		fmt.Fprintf(&buf, "Subject Alt Names:   %s\n", sans)
+	}
+	if len(ci.KeyUsage) > 0 {
+		fmt.Fprintf(&buf, "Key Usage:           %s\n", strings.Join(ci.KeyUsage, ", "))
+	}
+	if len(ci.ExtKeyUsage) > 0 {
+		fmt.Fprintf(&buf, "Extended Key Usage:  %s\n", strings.Join(ci.ExtKeyUsage, ", "))
+	}
+	if ci.IsCA != nil {
+		fmt.Fprintf(&buf, "Is CA:               %t\n", *ci.IsCA)
+	}
+	if len(ci.Extensions) > 0 {
+		fmt.Fprintf(&buf, "Requested Exts:      %s\n", strings.Join(extensionLabels(ci.Extensions), ", "))
+	}
+	if ci.SignatureValid {
+		fmt.Fprintf(&buf, "Signature:           valid\n")
+	} else {
+		fmt.Fprintf(&buf, "Signature:           INVALID (%s)\n", ci.SignatureError)
+	}
+
+	return buf.String()
+}
+
+// GetCSRInfoString renders the details of a certificate request.
+func GetCSRInfoString(csr *x509.CertificateRequest) string {
+	return GetCSRInfo(csr).Text()
+}
+
+func csrSANs(csr *x509.CertificateRequest) SANs {
+	var s SANs
+	s.DNS = csr.DNSNames
+	s.Email = csr.EmailAddresses
+	for _, ip := range csr.IPAddresses {
+		s.IP = append(s.IP, ip.String())
+	}
+	for _, u := range csr.URIs {
+		s.URI = append(s.URI, safeURI(u))
+	}
+	return s
+}
+
+func extKeyUsageOIDsToStrings(oids []asn1.ObjectIdentifier) []string {
+	var out []string
+	for _, oid := range oids {
+		if eku, ok := extKeyUsageOIDs[oid.String()]; ok {
+			out = append(out, extKeyUsageNames[eku])
+		} else {
+			out = append(out, FormatOID(oid.String()))
+		}
+	}
+	return out
+}
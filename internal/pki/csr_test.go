@@ -0,0 +1,90 @@
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"strings"
+	"testing"
+)
+
+// createTestCSR builds a CSR requesting KU, EKU and basic constraints
+func createTestCSR(t *testing.T) *x509.CertificateRequest {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	ku, err := asn1.Marshal(asn1.BitString{Bytes: []byte{0x80}, BitLength: 1}) // digitalSignature
+	if err != nil {
+		t.Fatal(err)
+	}
+	eku, err := asn1.Marshal([]asn1.ObjectIdentifier{{1, 3, 6, 1, 5, 5, 7, 3, 2}, {1, 2, 3, 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc, err := asn1.Marshal(struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "client.example.com"},
+		DNSNames: []string{"client.example.com"},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidExtKeyUsage, Critical: true, Value: ku},
+			{Id: oidExtExtKeyUsage, Value: eku},
+			{Id: oidExtBasicConstraints, Value: bc},
+		},
+	}, key)
+	if err != nil {
+		t.Fatalf("Failed to create CSR: %v", err)
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		t.Fatalf("Failed to parse CSR: %v", err)
+	}
+	return csr
+}
+
+// TestGetCSRInfo tests decoding of requested extensions
+func TestGetCSRInfo(t *testing.T) {
+	ci := GetCSRInfo(createTestCSR(t))
+
+	if ci.Subject != "CN=client.example.com" {
+		t.Errorf("Expected subject 'CN=client.example.com', got '%s'", ci.Subject)
+	}
+	if !ci.SignatureValid {
+		t.Errorf("Expected valid signature, got error: %s", ci.SignatureError)
+	}
+	if strings.Join(ci.KeyUsage, ",") != "DigitalSignature" {
+		t.Errorf("Expected KeyUsage [DigitalSignature], got %v", ci.KeyUsage)
+	}
+	if strings.Join(ci.ExtKeyUsage, ",") != "ClientAuth,1.2.3.4" {
+		t.Errorf("Expected ExtKeyUsage [ClientAuth 1.2.3.4], got %v", ci.ExtKeyUsage)
+	}
+	if ci.IsCA == nil || *ci.IsCA {
+		t.Errorf("Expected IsCA=false, got %v", ci.IsCA)
+	}
+	if ci.PublicKey.Curve != "P-256" {
+		t.Errorf("Expected P-256 key, got %+v", ci.PublicKey)
+	}
+}
+
+// TestGetCSRInfoBadSignature tests that a tampered CSR is reported
+func TestGetCSRInfoBadSignature(t *testing.T) {
+	csr := createTestCSR(t)
+	csr.Signature[len(csr.Signature)-1] ^= 0xFF
+
+	ci := GetCSRInfo(csr)
+	if ci.SignatureValid {
+		t.Error("Expected invalid signature")
+	}
+	if !strings.Contains(ci.Text(), "Signature:           INVALID") {
+		t.Errorf("Expected INVALID signature line, got:\n%s", ci.Text())
+	}
+}
@@ -0,0 +1,115 @@
+package pki
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// CTLog is one Certificate Transparency log from a log list.
+type CTLog struct {
+	Description string
+	Operator    string
+	URL         string
+	// State is the log's lifecycle state, e.g. "usable" or "retired".
+	State string
+	LogID []byte
+	Key   crypto.PublicKey
+}
+
+// CTLogList is a set of CT logs, indexed by log ID.
+type CTLogList struct {
+	Logs []*CTLog
+}
+
+// ctLogListJSON is the subset of the log list schema (v3, as published at
+// https://www.gstatic.com/ct/log_list/v3/log_list.json) that is used.
+type ctLogListJSON struct {
+	Operators []struct {
+		Name      string      `json:"name"`
+		Logs      []ctLogJSON `json:"logs"`
+		TiledLogs []ctLogJSON `json:"tiled_logs"`
+	} `json:"operators"`
+}
+
+type ctLogJSON struct {
+	Description   string                     `json:"description"`
+	LogID         []byte                     `json:"log_id"`
+	Key           []byte                     `json:"key"`
+	URL           string                     `json:"url"`
+	SubmissionURL string                     `json:"submission_url"`
+	State         map[string]json.RawMessage `json:"state"`
+}
+
+// LoadCTLogList reads a CT log list JSON file.
+func LoadCTLogList(path string) (*CTLogList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l, err := ParseCTLogList(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return l, nil
+}
+
+// ParseCTLogList parses a CT log list in the JSON format published for
+// Chrome and Apple. Log IDs missing from the list are computed from the
+// log keys.
+func ParseCTLogList(data []byte) (*CTLogList, error) {
+	var raw ctLogListJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	l := &CTLogList{}
+	for _, op := range raw.Operators {
+		for _, lj := range append(op.Logs, op.TiledLogs...) {
+			key, err := x509.ParsePKIXPublicKey(lj.Key)
+			if err != nil {
+				return nil, fmt.Errorf("log %q: %w", lj.Description, err)
+			}
+			log := &CTLog{
+				Description: lj.Description,
+				Operator:    op.Name,
+				URL:         lj.URL,
+				LogID:       lj.LogID,
+				Key:         key,
+			}
+			if log.URL == "" {
+				log.URL = lj.SubmissionURL
+			}
+			if len(log.LogID) == 0 {
+				id := sha256.Sum256(lj.Key)
+				log.LogID = id[:]
+			}
+			for state := range lj.State {
+				log.State = state
+			}
+			l.Logs = append(l.Logs, log)
+		}
+	}
+	if len(l.Logs) == 0 {
+		return nil, errors.New("no logs found in log list")
+	}
+	return l, nil
+}
+
+// Find returns the log with the given ID, or nil.
+func (l *CTLogList) Find(logID []byte) *CTLog {
+	if l == nil {
+		return nil
+	}
+	for _, log := range l.Logs {
+		if bytes.Equal(log.LogID, logID) {
+			return log
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package pki
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestParseCTLogList tests log list parsing and lookup by log ID
+func TestParseCTLogList(t *testing.T) {
+	log := newTestLog(t)
+
+	if len(log.list.Logs) != 1 {
+		t.Fatalf("Expected 1 log, got %d", len(log.list.Logs))
+	}
+	l := log.list.Logs[0]
+	if l.State != "usable" || l.URL != "https://ct.example.com/" {
+		t.Errorf("Unexpected log fields: %+v", l)
+	}
+	if !bytes.Equal(l.LogID, log.id) {
+		t.Error("Expected the log ID to be computed from the key")
+	}
+	if log.list.Find(log.id) != l {
+		t.Error("Expected Find to return the log")
+	}
+	if log.list.Find(make([]byte, 32)) != nil {
+		t.Error("Expected no log for an unknown ID")
+	}
+
+	if _, err := ParseCTLogList([]byte(`{"operators": []}`)); err == nil {
+		t.Error("Expected error for an empty log list")
+	}
+}
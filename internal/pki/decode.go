@@ -0,0 +1,194 @@
+package pki
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// ErrPasswordRequired is returned by DecodeAny for a PKCS#12 bundle that
+// cannot be opened without a password.
+var ErrPasswordRequired = errors.New("PKCS#12 bundle is password protected")
+
+// Decoded is the result of DecodeAny: the objects found in the input and
+// a description of how they were encoded.
+type Decoded struct {
+	// Format names the detected encoding, e.g. "PEM", "DER",
+	// "base64 DER (no PEM armor)", "PKCS#7 (DER)" or "PKCS#12".
+	Format string
+	Blocks []Block
+}
+
+// pfx is the outer PKCS#12 structure of RFC 7292, used only to recognize
+// the format.
+type pfx struct {
+	Version  int
+	AuthSafe pkcs7ContentInfo
+	MacData  asn1.RawValue `asn1:"optional"`
+}
+
+// DecodeAny detects how data is encoded and returns the objects it holds
+// as classified blocks. It accepts PEM (with CRLF line endings, indentation
+// or text before the first block), DER (one object or several
+// concatenated), base64 without PEM armor, PKCS#7 bundles and, given the
+// password, PKCS#12 bundles, whose certificates and key are returned as
+// separate blocks. Input that matches none of these is returned as a
+// single certificate block so that parsing it reports the error.
+func DecodeAny(data []byte, password string) (*Decoded, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	if bytes.Contains(data, []byte("-----BEGIN ")) {
+		if blocks := ReadBlocks(normalizePEM(data)); len(blocks) > 0 {
+			return &Decoded{Format: "PEM", Blocks: blocks}, nil
+		}
+	}
+
+	if d, err := decodeBinary(data, password); d != nil || err != nil {
+		return d, err
+	}
+
+	if der := decodeBareBase64(data); der != nil {
+		if d, err := decodeBinary(der, password); d != nil {
+			d.Format = "base64 " + d.Format + " (no PEM armor)"
+			return d, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Decoded{Format: "unrecognized", Blocks: []Block{{Kind: BlockCertificate, Bytes: data}}}, nil
+}
+
+// normalizePEM trims every line, which also drops carriage returns, so
+// that pasted or re-encoded PEM decodes.
+func normalizePEM(data []byte) []byte {
+	lines := bytes.Split(data, []byte("\n"))
+	for i, l := range lines {
+		lines[i] = bytes.TrimSpace(l)
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// decodeBinary recognizes DER input. It returns nil, nil when data is not
+// a sequence of well-formed DER objects.
+func decodeBinary(data []byte, password string) (*Decoded, error) {
+	if IsPKCS7(data) {
+		return &Decoded{Format: "PKCS#7 (DER)", Blocks: []Block{{Kind: BlockPKCS7, Type: "PKCS7", Bytes: data}}}, nil
+	}
+	if isPFX(data) {
+		blocks, err := decodePKCS12Blocks(data, password)
+		if err != nil {
+			return nil, err
+		}
+		return &Decoded{Format: "PKCS#12", Blocks: blocks}, nil
+	}
+
+	var objects [][]byte
+	for rest := data; len(rest) > 0; {
+		var raw asn1.RawValue
+		var err error
+		rest, err = asn1.Unmarshal(rest, &raw)
+		if err != nil || raw.Tag != asn1.TagSequence {
+			return nil, nil
+		}
+		objects = append(objects, raw.FullBytes)
+	}
+	if len(objects) == 0 {
+		return nil, nil
+	}
+
+	d := &Decoded{Format: "DER"}
+	if len(objects) > 1 {
+		d.Format = fmt.Sprintf("DER (%d concatenated objects)", len(objects))
+	}
+	for _, der := range objects {
+		d.Blocks = append(d.Blocks, classifyDER(der))
+	}
+	return d, nil
+}
+
+// classifyDER works out what a single DER object is by trying each parser
+// in turn. Objects nothing accepts are treated as certificates, the most
+// likely intent, so that the parse error is reported.
+func classifyDER(der []byte) Block {
+	if _, err := x509.ParseCertificate(der); err == nil {
+		return Block{Kind: BlockCertificate, Type: "CERTIFICATE", Bytes: der}
+	}
+	if _, err := x509.ParseCertificateRequest(der); err == nil {
+		return Block{Kind: BlockCSR, Type: "CERTIFICATE REQUEST", Bytes: der}
+	}
+	if _, err := x509.ParseRevocationList(der); err == nil {
+		return Block{Kind: BlockCRL, Type: "X509 CRL", Bytes: der}
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return Block{Kind: BlockPrivateKey, Type: "PRIVATE KEY", Bytes: der}
+	}
+	if _, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return Block{Kind: BlockPrivateKey, Type: "RSA PRIVATE KEY", Bytes: der}
+	}
+	if _, err := x509.ParseECPrivateKey(der); err == nil {
+		return Block{Kind: BlockPrivateKey, Type: "EC PRIVATE KEY", Bytes: der}
+	}
+	if _, err := x509.ParsePKIXPublicKey(der); err == nil {
+		return Block{Kind: BlockPublicKey, Type: "PUBLIC KEY", Bytes: der}
+	}
+	return Block{Kind: BlockCertificate, Bytes: der}
+}
+
+// decodeBareBase64 decodes base64 text without PEM armor, ignoring
+// whitespace. It returns nil unless the result looks like DER.
+func decodeBareBase64(data []byte) []byte {
+	text := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, string(data))
+	if text == "" {
+		return nil
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if der, err := enc.DecodeString(text); err == nil && len(der) > 0 && der[0] == 0x30 {
+			return der
+		}
+	}
+	return nil
+}
+
+func isPFX(data []byte) bool {
+	var p pfx
+	rest, err := asn1.Unmarshal(data, &p)
+	return err == nil && len(rest) == 0 && p.Version == 3
+}
+
+// decodePKCS12Blocks opens a PKCS#12 bundle and returns its certificates,
+// then its private key as a PKCS#8 block.
+func decodePKCS12Blocks(data []byte, password string) ([]Block, error) {
+	b, err := DecodePKCS12(data, password)
+	if err != nil {
+		if password == "" && errors.Is(err, pkcs12.ErrIncorrectPassword) {
+			return nil, ErrPasswordRequired
+		}
+		return nil, err
+	}
+
+	var blocks []Block
+	for _, c := range b.Certificates {
+		blocks = append(blocks, Block{Kind: BlockCertificate, Type: "CERTIFICATE", Bytes: c.Raw})
+	}
+	if b.Key != nil {
+		der, err := x509.MarshalPKCS8PrivateKey(b.Key)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, Block{Kind: BlockPrivateKey, Type: "PRIVATE KEY", Bytes: der})
+	}
+	return blocks, nil
+}
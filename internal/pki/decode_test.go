@@ -0,0 +1,144 @@
+package pki
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestDecodeAny tests format detection for the encodings DecodeAny accepts
+func TestDecodeAny(t *testing.T) {
+	root := newTestRoot(t, "Decode Root")
+	leaf := root.leaf(t, "decode.example.com")
+	keyDER, err := x509.MarshalPKCS8PrivateKey(root.key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	p7, err := EncodePKCS7([]*x509.Certificate{leaf, root.cert})
+	if err != nil {
+		t.Fatalf("EncodePKCS7 failed: %v", err)
+	}
+
+	pemChain := string(EncodeCertsPEM(leaf, root.cert))
+	crlf := "subject=CN=decode.example.com\r\nissuer=CN=Decode Root\r\n" + strings.ReplaceAll(pemChain, "\n", "\r\n")
+	indented := "    " + strings.ReplaceAll(strings.TrimSpace(pemChain), "\n", "\n    ") + "\n"
+	b64 := base64.StdEncoding.EncodeToString(leaf.Raw)
+	wrapped := b64[:64] + "\r\n" + b64[64:] + "\n"
+
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+		kinds  []BlockKind
+	}{
+		{"PEM", []byte(pemChain), "PEM", []BlockKind{BlockCertificate, BlockCertificate}},
+		{"PEM with CRLF and prefix", []byte(crlf), "PEM", []BlockKind{BlockCertificate, BlockCertificate}},
+		{"indented PEM", []byte(indented), "PEM", []BlockKind{BlockCertificate, BlockCertificate}},
+		{"DER", leaf.Raw, "DER", []BlockKind{BlockCertificate}},
+		{"concatenated DER", append(append([]byte{}, leaf.Raw...), keyDER...), "DER (2 concatenated objects)", []BlockKind{BlockCertificate, BlockPrivateKey}},
+		{"base64", []byte(wrapped), "base64 DER (no PEM armor)", []BlockKind{BlockCertificate}},
+		{"PKCS#7", p7, "PKCS#7 (DER)", []BlockKind{BlockPKCS7}},
+		{"garbage", []byte("hello world"), "unrecognized", []BlockKind{BlockCertificate}},
+	}
+	for _, tt := range tests {
+		d, err := DecodeAny(tt.data, "")
+		if err != nil {
+			t.Errorf("%s: DecodeAny failed: %v", tt.name, err)
+			continue
+		}
+		if d.Format != tt.format {
+			t.Errorf("%s: expected format '%s', got '%s'", tt.name, tt.format, d.Format)
+		}
+		if len(d.Blocks) != len(tt.kinds) {
+			t.Errorf("%s: expected %d blocks, got %d", tt.name, len(tt.kinds), len(d.Blocks))
+			continue
+		}
+		for i, k := range tt.kinds {
+			if d.Blocks[i].Kind != k {
+				t.Errorf("%s: block #%d: expected %s, got %s", tt.name, i+1, k, d.Blocks[i].Kind)
+			}
+		}
+	}
+}
+
+// TestDecodeAnyPKCS12 tests that PKCS#12 needs its password and is flattened
+func TestDecodeAnyPKCS12(t *testing.T) {
+	root := newTestRoot(t, "Decode Root")
+	leaf, key := root.issue(t, &x509.Certificate{DNSNames: []string{"p12.example.com"}})
+	pfxData, err := EncodePKCS12(key, []*x509.Certificate{leaf, root.cert}, "secret")
+	if err != nil {
+		t.Fatalf("EncodePKCS12 failed: %v", err)
+	}
+
+	if _, err := DecodeAny(pfxData, ""); !errors.Is(err, ErrPasswordRequired) {
+		t.Errorf("Expected ErrPasswordRequired, got %v", err)
+	}
+	if _, err := DecodeAny(pfxData, "wrong"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("Expected ErrIncorrectPassword, got %v", err)
+	}
+
+	d, err := DecodeAny(pfxData, "secret")
+	if err != nil {
+		t.Fatalf("DecodeAny failed: %v", err)
+	}
+	if d.Format != "PKCS#12" {
+		t.Errorf("Expected format 'PKCS#12', got '%s'", d.Format)
+	}
+	want := []BlockKind{BlockCertificate, BlockCertificate, BlockPrivateKey}
+	if len(d.Blocks) != len(want) {
+		t.Fatalf("Expected %d blocks, got %d", len(want), len(d.Blocks))
+	}
+	for i, k := range want {
+		if d.Blocks[i].Kind != k {
+			t.Errorf("Block #%d: expected %s, got %s", i+1, k, d.Blocks[i].Kind)
+		}
+		if _, err := d.Blocks[i].Summary(); err != nil {
+			t.Errorf("Block #%d: Summary failed: %v", i+1, err)
+		}
+	}
+}
+
+// TestBlockFormatError tests the error wording shared by the frontends
+func TestBlockFormatError(t *testing.T) {
+	err := errors.New("boom")
+	tests := []struct {
+		kind BlockKind
+		want string
+	}{
+		{BlockCertificate, "not a certificate: boom"},
+		{BlockCRL, "invalid crl: boom"},
+		{BlockUnknown, "boom"},
+	}
+	for _, tt := range tests {
+		if got := (Block{Kind: tt.kind}).FormatError(err); got != tt.want {
+			t.Errorf("Expected '%s', got '%s'", tt.want, got)
+		}
+	}
+}
+
+// TestDecodedCertificatesPartial tests that a bad block is reported without
+// dropping the certificates around it
+func TestDecodedCertificatesPartial(t *testing.T) {
+	root := newTestRoot(t, "Decode Root")
+	leaf := root.leaf(t, "decode.example.com")
+	bad := "-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n"
+	data := string(EncodeCertsPEM(leaf)) + bad + string(EncodeCertsPEM(root.cert))
+
+	d, err := DecodeAny([]byte(data), "")
+	if err != nil {
+		t.Fatalf("DecodeAny failed: %v", err)
+	}
+	certs, err := d.Certificates()
+	if err == nil || !strings.Contains(err.Error(), "block #2") {
+		t.Errorf("Expected an error naming block #2, got %v", err)
+	}
+	if len(certs) != 2 || !certs[0].Equal(leaf) || !certs[1].Equal(root.cert) {
+		t.Errorf("Expected the leaf and root to parse, got %d certificates", len(certs))
+	}
+
+	if certs, err := ParseCertificates([]byte(data)); err == nil || certs != nil {
+		t.Errorf("Expected ParseCertificates to fail on the bad block, got %d certificates", len(certs))
+	}
+}
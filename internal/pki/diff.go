@@ -0,0 +1,123 @@
+package pki
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Change is one field that differs between two certificates. Scalar fields
+// set Old and New; list-valued fields set Added and Removed instead.
+type Change struct {
+	Field   string   `json:"field" yaml:"field"`
+	Old     string   `json:"old,omitempty" yaml:"old,omitempty"`
+	New     string   `json:"new,omitempty" yaml:"new,omitempty"`
+	Added   []string `json:"added,omitempty" yaml:"added,omitempty"`
+	Removed []string `json:"removed,omitempty" yaml:"removed,omitempty"`
+	// Significant is false for fields that are expected to change on every
+	// renewal (serial, validity dates, key identifiers, fingerprint).
+	Significant bool `json:"significant" yaml:"significant"`
+}
+
+// CertDiff is the field-by-field comparison of two certificates.
+type CertDiff struct {
+	Changes []Change `json:"changes" yaml:"changes"`
+}
+
+// Significant reports whether any change goes beyond a plain renewal.
+func (d *CertDiff) Significant() bool {
+	for _, c := range d.Changes {
+		if c.Significant {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffCertInfo compares a (the old certificate) with b (the new one).
+func DiffCertInfo(a, b *CertInfo) *CertDiff {
+	d := &CertDiff{Changes: []Change{}}
+
+	scalar := func(field, old, new string, significant bool) {
+		if old != new {
+			d.Changes = append(d.Changes, Change{Field: field, Old: old, New: new, Significant: significant})
+		}
+	}
+	list := func(field string, old, new []string, significant bool) {
+		added, removed := setDiff(old, new)
+		if len(added) > 0 || len(removed) > 0 {
+			d.Changes = append(d.Changes, Change{Field: field, Added: added, Removed: removed, Significant: significant})
+		}
+	}
+
+	scalar("Subject", a.Subject, b.Subject, true)
+	scalar("Issuer", a.Issuer, b.Issuer, true)
+	scalar("Serial", a.Serial, b.Serial, false)
+	scalar("Version", strconv.Itoa(a.Version), strconv.Itoa(b.Version), true)
+	scalar("Signature Algorithm", a.SignatureAlgorithm, b.SignatureAlgorithm, true)
+	scalar("Public Key", a.PublicKey.Summary, b.PublicKey.Summary, true)
+	scalar("Not Before", a.NotBefore.Format(time.RFC3339), b.NotBefore.Format(time.RFC3339), false)
+	scalar("Not After", a.NotAfter.Format(time.RFC3339), b.NotAfter.Format(time.RFC3339), false)
+	scalar("Validity Period", validityPeriod(a), validityPeriod(b), false)
+	scalar("Is CA", strconv.FormatBool(a.IsCA), strconv.FormatBool(b.IsCA), true)
+	scalar("Path Len", pathLenString(a.PathLen), pathLenString(b.PathLen), true)
+	list("Key Usage", a.KeyUsage, b.KeyUsage, true)
+	list("Extended Key Usage", a.ExtKeyUsage, b.ExtKeyUsage, true)
+	list("SAN DNS", a.SANs.DNS, b.SANs.DNS, true)
+	list("SAN Email", a.SANs.Email, b.SANs.Email, true)
+	list("SAN IP", a.SANs.IP, b.SANs.IP, true)
+	list("SAN URI", a.SANs.URI, b.SANs.URI, true)
+	scalar("Subject Key ID", a.SubjectKeyID, b.SubjectKeyID, false)
+	scalar("Authority Key ID", a.AuthorityKeyID, b.AuthorityKeyID, false)
+	list("OCSP", a.OCSPServers, b.OCSPServers, true)
+	list("CRL Distribution", a.CRLDistribution, b.CRLDistribution, true)
+	list("AIA Issuer URL", a.IssuingCertURLs, b.IssuingCertURLs, true)
+	list("Policy OIDs", formatOIDs(a.PolicyOIDs), formatOIDs(b.PolicyOIDs), true)
+	list("Extensions", extensionLabels(a.Extensions), extensionLabels(b.Extensions), true)
+	scalar("Fingerprint SHA-256", a.Fingerprints.SHA256, b.Fingerprints.SHA256, false)
+
+	return d
+}
+
+// setDiff returns the entries of new missing from old and vice versa, in
+// their original order.
+func setDiff(old, new []string) (added, removed []string) {
+	inOld := make(map[string]bool, len(old))
+	for _, s := range old {
+		inOld[s] = true
+	}
+	inNew := make(map[string]bool, len(new))
+	for _, s := range new {
+		inNew[s] = true
+		if !inOld[s] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range old {
+		if !inNew[s] {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+func validityPeriod(ci *CertInfo) string {
+	return ci.NotAfter.Sub(ci.NotBefore).Round(time.Second).String()
+}
+
+func pathLenString(n *int) string {
+	if n == nil {
+		return "unlimited"
+	}
+	return strconv.Itoa(*n)
+}
+
+// extensionLabels names extensions by name, OID and criticality, so that a
+// criticality change shows up as one removed and one added entry.
+func extensionLabels(exts []Extension) []string {
+	var out []string
+	for _, e := range exts {
+		out = append(out, strings.TrimSuffix(e.heading(), ":"))
+	}
+	return out
+}
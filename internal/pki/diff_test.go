@@ -0,0 +1,69 @@
+package pki
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"reflect"
+	"testing"
+)
+
+// TestDiffCertInfoRenewal tests that a plain renewal is not significant
+func TestDiffCertInfoRenewal(t *testing.T) {
+	root := newTestRoot(t, "Diff Root")
+	a := GetCertInfo(root.leaf(t, "www.example.com", "example.com"))
+	b := GetCertInfo(root.leaf(t, "www.example.com", "example.com"))
+
+	d := DiffCertInfo(a, b)
+	if d.Significant() {
+		t.Errorf("Expected renewal to be insignificant, got %+v", d.Changes)
+	}
+	if len(d.Changes) == 0 {
+		t.Error("Expected serial and fingerprint changes")
+	}
+	for _, c := range d.Changes {
+		if c.Field == "Subject" || c.Field == "SAN DNS" {
+			t.Errorf("Unexpected change in %s", c.Field)
+		}
+	}
+
+	if d := DiffCertInfo(a, a); len(d.Changes) != 0 {
+		t.Errorf("Expected no changes comparing a certificate with itself, got %+v", d.Changes)
+	}
+}
+
+// TestDiffCertInfoChanges tests SAN, EKU and extension differences
+func TestDiffCertInfoChanges(t *testing.T) {
+	root := newTestRoot(t, "Diff Root")
+	a := GetCertInfo(root.leaf(t, "www.example.com", "old.example.com"))
+	cert, _ := root.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "www.example.com"},
+		DNSNames:    []string{"www.example.com", "new.example.com"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		OCSPServer:  []string{"http://ocsp.example.com"},
+	})
+	b := GetCertInfo(cert)
+
+	d := DiffCertInfo(a, b)
+	if !d.Significant() {
+		t.Fatal("Expected significant changes")
+	}
+
+	changes := map[string]Change{}
+	for _, c := range d.Changes {
+		changes[c.Field] = c
+	}
+	san := changes["SAN DNS"]
+	if !reflect.DeepEqual(san.Added, []string{"new.example.com"}) || !reflect.DeepEqual(san.Removed, []string{"old.example.com"}) {
+		t.Errorf("Expected SAN change +new.example.com -old.example.com, got %+v", san)
+	}
+	if eku := changes["Extended Key Usage"]; !reflect.DeepEqual(eku.Added, []string{"ClientAuth"}) || len(eku.Removed) != 0 {
+		t.Errorf("Expected EKU +ClientAuth, got %+v", eku)
+	}
+	if ocsp := changes["OCSP"]; !ocsp.Significant || len(ocsp.Added) != 1 {
+		t.Errorf("Expected significant OCSP change, got %+v", ocsp)
+	}
+	if ext := changes["Extensions"]; !reflect.DeepEqual(ext.Added, []string{"Authority Information Access (1.3.6.1.5.5.7.1.1)"}) {
+		t.Errorf("Expected AIA extension to be added, got %+v", ext)
+	}
+}
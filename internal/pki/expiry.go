@@ -0,0 +1,151 @@
+package pki
+
+import (
+	"crypto/x509"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Expiry statuses, from least to most urgent.
+const (
+	ExpiryOK       = "ok"
+	ExpiryWarning  = "warning"
+	ExpiryCritical = "critical"
+	ExpiryExpired  = "expired"
+	ExpiryError    = "error"
+)
+
+// ExpiryThresholds are the number of days before expiry at which a
+// certificate becomes a warning or critical.
+type ExpiryThresholds struct {
+	Warning  int
+	Critical int
+}
+
+// ExpiryEntry is the expiry status of one certificate, or the reason a
+// source could not be checked.
+type ExpiryEntry struct {
+	// Source is the file path or host:port the certificate came from and
+	// Index its 1-based position in that file or presented chain.
+	Source        string     `json:"source" yaml:"source"`
+	Index         int        `json:"index" yaml:"index"`
+	Subject       string     `json:"subject,omitempty" yaml:"subject,omitempty"`
+	Serial        string     `json:"serial,omitempty" yaml:"serial,omitempty"`
+	NotAfter      *time.Time `json:"not_after,omitempty" yaml:"not_after,omitempty"`
+	DaysRemaining int        `json:"days_remaining" yaml:"days_remaining"`
+	Status        string     `json:"status" yaml:"status"`
+	Error         string     `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// CheckExpiry returns an entry per certificate in certs, which were read
+// from source.
+func CheckExpiry(source string, certs []*x509.Certificate, now time.Time, th ExpiryThresholds) []ExpiryEntry {
+	var out []ExpiryEntry
+	for i, c := range certs {
+		// Days are rounded down, so a certificate expiring later today
+		// has 0 days left and an expired one a negative count.
+		days := int(math.Floor(c.NotAfter.Sub(now).Hours() / 24))
+		notAfter := c.NotAfter
+		e := ExpiryEntry{
+			Source:        source,
+			Index:         i + 1,
+			Subject:       FormatName(c.Subject),
+			Serial:        hexifyBigInt(c.SerialNumber),
+			NotAfter:      &notAfter,
+			DaysRemaining: days,
+		}
+		switch {
+		case now.After(c.NotAfter):
+			e.Status = ExpiryExpired
+		case days < th.Critical:
+			e.Status = ExpiryCritical
+		case days < th.Warning:
+			e.Status = ExpiryWarning
+		default:
+			e.Status = ExpiryOK
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// ScanExpiryDir walks root and checks every certificate in PEM or DER files
+// below it. Files that hold no certificates (keys, CSRs, unrelated files)
+// are skipped; unreadable files and certificate blocks that do not parse
+// are reported as error entries.
+func ScanExpiryDir(root string, now time.Time, th ExpiryThresholds) ([]ExpiryEntry, error) {
+	var out []ExpiryEntry
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			out = append(out, ExpiryEntry{Source: path, Status: ExpiryError, Error: err.Error()})
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			out = append(out, ExpiryEntry{Source: path, Status: ExpiryError, Error: err.Error()})
+			return nil
+		}
+		out = append(out, expiryFromFile(path, data, now, th)...)
+		return nil
+	})
+	return out, err
+}
+
+func expiryFromFile(path string, data []byte, now time.Time, th ExpiryThresholds) []ExpiryEntry {
+	blocks := ReadPEMBlocks(data)
+	if len(blocks) == 0 {
+		if len(ReadBlocks(data)) > 0 {
+			// PEM, but no certificates.
+			return nil
+		}
+		cert, err := TryParseCert(data)
+		if err != nil {
+			// Not a DER certificate either.
+			return nil
+		}
+		return CheckExpiry(path, []*x509.Certificate{cert}, now, th)
+	}
+
+	var out []ExpiryEntry
+	for i, b := range blocks {
+		cert, err := TryParseCert(b)
+		if err != nil {
+			out = append(out, ExpiryEntry{Source: path, Index: i + 1, Status: ExpiryError, Error: err.Error()})
+			continue
+		}
+		e := CheckExpiry(path, []*x509.Certificate{cert}, now, th)[0]
+		e.Index = i + 1
+		out = append(out, e)
+	}
+	return out
+}
+
+// expiryRank orders statuses by urgency, most urgent first.
+var expiryRank = map[string]int{
+	ExpiryError:    0,
+	ExpiryExpired:  1,
+	ExpiryCritical: 2,
+	ExpiryWarning:  3,
+	ExpiryOK:       4,
+}
+
+// SortExpiry sorts entries by urgency: errors first, then by expiry date.
+func SortExpiry(entries []ExpiryEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Status == ExpiryError || b.Status == ExpiryError {
+			return expiryRank[a.Status] < expiryRank[b.Status]
+		}
+		return a.NotAfter.Before(*b.NotAfter)
+	})
+}
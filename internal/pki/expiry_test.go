@@ -0,0 +1,104 @@
+package pki
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestCheckExpiry tests days remaining and status thresholds
+func TestCheckExpiry(t *testing.T) {
+	root := newTestRoot(t, "Expiry Root")
+	now := time.Now()
+	th := ExpiryThresholds{Warning: 30, Critical: 7}
+
+	tests := []struct {
+		notAfter time.Time
+		days     int
+		status   string
+	}{
+		{now.Add(100*24*time.Hour + time.Hour), 100, ExpiryOK},
+		{now.Add(20*24*time.Hour + time.Hour), 20, ExpiryWarning},
+		{now.Add(3*24*time.Hour + time.Hour), 3, ExpiryCritical},
+		{now.Add(-2*24*time.Hour + time.Hour), -2, ExpiryExpired},
+	}
+	for _, tt := range tests {
+		cert, _ := root.issue(t, &x509.Certificate{
+			Subject:   pkix.Name{CommonName: "expiry.example.com"},
+			NotBefore: now.Add(-10 * 24 * time.Hour),
+			NotAfter:  tt.notAfter,
+		})
+		e := CheckExpiry("test", []*x509.Certificate{cert}, now, th)[0]
+		if e.DaysRemaining != tt.days || e.Status != tt.status {
+			t.Errorf("Expected %d days (%s), got %d (%s)", tt.days, tt.status, e.DaysRemaining, e.Status)
+		}
+	}
+}
+
+// TestScanExpiryDir tests walking a directory of mixed files
+func TestScanExpiryDir(t *testing.T) {
+	root := newTestRoot(t, "Expiry Root")
+	soon, _ := root.issue(t, &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "soon.example.com"},
+		NotAfter: time.Now().Add(2 * 24 * time.Hour),
+	})
+	later, _ := root.issue(t, &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "later.example.com"},
+		NotAfter: time.Now().Add(200 * 24 * time.Hour),
+	})
+
+	dir := t.TempDir()
+	key, err := EncodeKeyPEM(root.key)
+	if err != nil {
+		t.Fatalf("Failed to encode key: %v", err)
+	}
+	files := map[string][]byte{
+		"bundle.pem":   EncodeCertsPEM(later, root.cert),
+		"sub/soon.der": soon.Raw,
+		"ca.key":       key,
+		"notes.txt":    []byte("not a certificate"),
+		"broken.crt":   []byte("-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n"),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := ScanExpiryDir(dir, time.Now(), ExpiryThresholds{Warning: 30, Critical: 7})
+	if err != nil {
+		t.Fatalf("ScanExpiryDir failed: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 entries, got %d: %+v", len(entries), entries)
+	}
+
+	SortExpiry(entries)
+	if entries[0].Status != ExpiryError || filepath.Base(entries[0].Source) != "broken.crt" {
+		t.Errorf("Expected broken.crt error first, got %+v", entries[0])
+	}
+	// The test root expires within a day, before soon.example.com.
+	if entries[1].Subject != "CN=Expiry Root" || entries[1].Index != 2 {
+		t.Errorf("Expected the root (#2) second, got %+v", entries[1])
+	}
+	if entries[2].Subject != "CN=soon.example.com" || entries[2].Status != ExpiryCritical {
+		t.Errorf("Expected soon.example.com critical third, got %+v", entries[2])
+	}
+	if entries[3].Subject != "CN=later.example.com" || entries[3].Index != 1 {
+		t.Errorf("Expected later.example.com (#1) last, got %+v", entries[3])
+	}
+}
+
+// TestScanExpiryDirMissing tests that a missing root is an error
+func TestScanExpiryDirMissing(t *testing.T) {
+	if _, err := ScanExpiryDir(filepath.Join(t.TempDir(), "missing"), time.Now(), ExpiryThresholds{}); err == nil {
+		t.Error("Expected error for missing directory")
+	}
+}
@@ -0,0 +1,540 @@
+package pki
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// extensionDecoder renders an extension value as readable lines.
+type extensionDecoder func(value []byte) ([]string, error)
+
+// extensionDecoders are keyed by dotted OID. Extension names come from the
+// OID registry.
+var extensionDecoders = map[string]extensionDecoder{
+	"2.5.29.14":               decodeSubjectKeyID,
+	"2.5.29.15":               decodeKeyUsage,
+	"2.5.29.17":               decodeGeneralNamesExt,
+	"2.5.29.18":               decodeGeneralNamesExt,
+	"2.5.29.19":               decodeBasicConstraints,
+	"2.5.29.30":               decodeNameConstraints,
+	"2.5.29.31":               decodeCRLDistributionPoints,
+	"2.5.29.32":               decodeCertificatePolicies,
+	"2.5.29.33":               decodePolicyMappings,
+	"2.5.29.35":               decodeAuthorityKeyID,
+	"2.5.29.36":               decodePolicyConstraints,
+	"2.5.29.37":               decodeExtKeyUsage,
+	"2.5.29.54":               decodeInhibitAnyPolicy,
+	"1.3.6.1.5.5.7.1.1":       decodeInfoAccess,
+	"1.3.6.1.5.5.7.1.11":      decodeInfoAccess,
+	"1.3.6.1.5.5.7.1.24":      decodeTLSFeature,
+	"1.3.6.1.5.5.7.48.1.5":    decodeNull,
+	oidSCTList:                decodeSCTList,
+	"1.3.6.1.4.1.11129.2.4.3": decodeNull,
+	"1.3.6.1.4.1.311.20.2":    decodeDirectoryString,
+	"1.3.6.1.4.1.311.21.1":    decodeMSCAVersion,
+	"1.3.6.1.4.1.311.21.2":    decodeOctetString,
+	"1.3.6.1.4.1.311.21.7":    decodeMSTemplate,
+	"1.3.6.1.4.1.311.21.10":   decodeMSApplicationPolicies,
+	"2.16.840.1.113730.1.13":  decodeDirectoryString,
+}
+
+const policyQualifierCPS = "1.3.6.1.5.5.7.2.1"
+
+var tlsFeatureNames = map[int]string{
+	5:  "status_request (OCSP Must-Staple)",
+	17: "status_request_v2",
+}
+
+const oidUPN = "1.3.6.1.4.1.311.20.2.3"
+
+// newExtension builds the structured view of e, decoding it when the OID
+// is known and falling back to an ASN.1 dump otherwise.
+func newExtension(e pkix.Extension) Extension {
+	ext := Extension{
+		OID:      e.Id.String(),
+		Critical: e.Critical,
+		Value:    hex.EncodeToString(e.Value),
+	}
+	ext.Name = OIDName(ext.OID)
+	if decode, ok := extensionDecoders[ext.OID]; ok {
+		lines, err := decode(e.Value)
+		if err == nil {
+			ext.Decoded = lines
+			return ext
+		}
+		ext.Decoded = append([]string{fmt.Sprintf("(could not decode: %v)", err)}, dumpASN1(e.Value)...)
+		return ext
+	}
+	ext.Decoded = dumpASN1(e.Value)
+	return ext
+}
+
+// unmarshalAll decodes der into out and rejects trailing data.
+func unmarshalAll(der []byte, out any) error {
+	rest, err := asn1.Unmarshal(der, out)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return errors.New("trailing data")
+	}
+	return nil
+}
+
+// sequenceElements returns the elements of a DER SEQUENCE (or SET).
+func sequenceElements(der []byte) ([]asn1.RawValue, error) {
+	var seq asn1.RawValue
+	if err := unmarshalAll(der, &seq); err != nil {
+		return nil, err
+	}
+	if !seq.IsCompound {
+		return nil, errors.New("expected a constructed value")
+	}
+	return rawElements(seq.Bytes)
+}
+
+// rawElements splits concatenated DER elements.
+func rawElements(b []byte) ([]asn1.RawValue, error) {
+	var out []asn1.RawValue
+	for len(b) > 0 {
+		var rv asn1.RawValue
+		var err error
+		if b, err = asn1.Unmarshal(b, &rv); err != nil {
+			return nil, err
+		}
+		out = append(out, rv)
+	}
+	return out, nil
+}
+
+func decodeSubjectKeyID(v []byte) ([]string, error) {
+	var id []byte
+	if err := unmarshalAll(v, &id); err != nil {
+		return nil, err
+	}
+	return []string{hexColon(id)}, nil
+}
+
+func decodeKeyUsage(v []byte) ([]string, error) {
+	var bits asn1.BitString
+	if err := unmarshalAll(v, &bits); err != nil {
+		return nil, err
+	}
+	return []string{strings.Join(keyUsageToStrings(keyUsageFromBits(bits)), ", ")}, nil
+}
+
+// keyUsageFromBits maps the KeyUsage BIT STRING to x509.KeyUsage.
+func keyUsageFromBits(bits asn1.BitString) x509.KeyUsage {
+	var ku x509.KeyUsage
+	for i := 0; i < 9; i++ {
+		if bits.At(i) != 0 {
+			ku |= 1 << uint(i)
+		}
+	}
+	return ku
+}
+
+func decodeExtKeyUsage(v []byte) ([]string, error) {
+	var oids []asn1.ObjectIdentifier
+	if err := unmarshalAll(v, &oids); err != nil {
+		return nil, err
+	}
+	return []string{strings.Join(extKeyUsageOIDsToStrings(oids), ", ")}, nil
+}
+
+func decodeBasicConstraints(v []byte) ([]string, error) {
+	var bc struct {
+		IsCA       bool `asn1:"optional"`
+		MaxPathLen int  `asn1:"optional,default:-1"`
+	}
+	if err := unmarshalAll(v, &bc); err != nil {
+		return nil, err
+	}
+	s := "CA:FALSE"
+	if bc.IsCA {
+		s = "CA:TRUE"
+	}
+	if bc.MaxPathLen >= 0 {
+		s += fmt.Sprintf(", pathlen:%d", bc.MaxPathLen)
+	}
+	return []string{s}, nil
+}
+
+func decodeGeneralNamesExt(v []byte) ([]string, error) {
+	elems, err := sequenceElements(v)
+	if err != nil {
+		return nil, err
+	}
+	return generalNames(elems), nil
+}
+
+func generalNames(elems []asn1.RawValue) []string {
+	var out []string
+	for _, rv := range elems {
+		out = append(out, generalName(rv, false))
+	}
+	return out
+}
+
+// generalName renders a GeneralName as "TYPE:value". In name constraints
+// iPAddress holds an address and mask, rendered as a CIDR.
+func generalName(rv asn1.RawValue, constraint bool) string {
+	if rv.Class != asn1.ClassContextSpecific {
+		return "invalid:" + hex.EncodeToString(rv.FullBytes)
+	}
+	switch rv.Tag {
+	case 0:
+		var on struct {
+			TypeID asn1.ObjectIdentifier
+			Value  asn1.RawValue `asn1:"explicit,tag:0"`
+		}
+		if _, err := asn1.UnmarshalWithParams(rv.FullBytes, &on, "tag:0"); err != nil {
+			return "othername:" + hex.EncodeToString(rv.Bytes)
+		}
+		if on.TypeID.String() == oidUPN {
+			return "UPN:" + asn1String(on.Value)
+		}
+		return fmt.Sprintf("othername:%s:%s", FormatOID(on.TypeID.String()), strings.Join(dumpASN1(on.Value.FullBytes), " "))
+	case 1:
+		return "email:" + string(rv.Bytes)
+	case 2:
+		return "DNS:" + string(rv.Bytes)
+	case 4:
+		var rdn pkix.RDNSequence
+		if err := unmarshalAll(rv.Bytes, &rdn); err != nil {
+			return "DirName:" + hex.EncodeToString(rv.Bytes)
+		}
+		var name pkix.Name
+		name.FillFromRDNSequence(&rdn)
+		return "DirName:" + FormatName(name)
+	case 6:
+		return "URI:" + string(rv.Bytes)
+	case 7:
+		if constraint && (len(rv.Bytes) == 8 || len(rv.Bytes) == 32) {
+			n := len(rv.Bytes) / 2
+			ipNet := net.IPNet{IP: rv.Bytes[:n], Mask: rv.Bytes[n:]}
+			return "IP:" + ipNet.String()
+		}
+		if len(rv.Bytes) == 4 || len(rv.Bytes) == 16 {
+			return "IP:" + net.IP(rv.Bytes).String()
+		}
+		return "IP:" + hex.EncodeToString(rv.Bytes)
+	case 8:
+		var oid asn1.ObjectIdentifier
+		if _, err := asn1.UnmarshalWithParams(rv.FullBytes, &oid, "tag:8"); err == nil {
+			return "RID:" + oid.String()
+		}
+	}
+	return fmt.Sprintf("[%d]:%s", rv.Tag, hex.EncodeToString(rv.Bytes))
+}
+
+func decodeNameConstraints(v []byte) ([]string, error) {
+	elems, err := sequenceElements(v)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, subtrees := range elems {
+		var label string
+		switch subtrees.Tag {
+		case 0:
+			label = "Permitted"
+		case 1:
+			label = "Excluded"
+		default:
+			return nil, fmt.Errorf("unexpected tag [%d]", subtrees.Tag)
+		}
+		trees, err := rawElements(subtrees.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		for _, tree := range trees {
+			parts, err := rawElements(tree.Bytes)
+			if err != nil || len(parts) == 0 {
+				return nil, errors.New("malformed GeneralSubtree")
+			}
+			out = append(out, fmt.Sprintf("%s: %s", label, generalName(parts[0], true)))
+		}
+	}
+	return out, nil
+}
+
+func decodeCRLDistributionPoints(v []byte) ([]string, error) {
+	dps, err := sequenceElements(v)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, dp := range dps {
+		fields, err := rawElements(dp.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			switch f.Tag {
+			case 0:
+				// DistributionPointName is a CHOICE, so [0] is explicit.
+				names, err := rawElements(f.Bytes)
+				if err != nil || len(names) != 1 {
+					return nil, errors.New("malformed DistributionPointName")
+				}
+				if names[0].Tag == 0 {
+					gns, err := rawElements(names[0].Bytes)
+					if err != nil {
+						return nil, err
+					}
+					for _, gn := range generalNames(gns) {
+						out = append(out, "Full Name: "+gn)
+					}
+				} else {
+					out = append(out, "Relative Name: "+hex.EncodeToString(names[0].Bytes))
+				}
+			case 1:
+				out = append(out, "Reasons: "+hex.EncodeToString(f.Bytes))
+			case 2:
+				gns, err := rawElements(f.Bytes)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, "CRL Issuer: "+strings.Join(generalNames(gns), ", "))
+			}
+		}
+	}
+	return out, nil
+}
+
+func decodeCertificatePolicies(v []byte) ([]string, error) {
+	var policies []struct {
+		Policy     asn1.ObjectIdentifier
+		Qualifiers []struct {
+			ID        asn1.ObjectIdentifier
+			Qualifier asn1.RawValue
+		} `asn1:"optional"`
+	}
+	if err := unmarshalAll(v, &policies); err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, p := range policies {
+		out = append(out, "Policy: "+FormatOID(p.Policy.String()))
+		for _, q := range p.Qualifiers {
+			if q.ID.String() == policyQualifierCPS {
+				out = append(out, "  CPS: "+asn1String(q.Qualifier))
+				continue
+			}
+			out = append(out, userNotice(q.Qualifier)...)
+		}
+	}
+	return out, nil
+}
+
+// userNotice renders a UserNotice policy qualifier: an optional
+// NoticeReference (organization and notice numbers) and explicit text.
+func userNotice(rv asn1.RawValue) []string {
+	parts, err := rawElements(rv.Bytes)
+	if err != nil {
+		return []string{"  User Notice: " + hex.EncodeToString(rv.Bytes)}
+	}
+	var out []string
+	for _, p := range parts {
+		if p.Tag == asn1.TagSequence && p.IsCompound {
+			var ref struct {
+				Organization asn1.RawValue
+				Numbers      []int
+			}
+			if err := unmarshalAll(p.FullBytes, &ref); err == nil {
+				out = append(out, fmt.Sprintf("  User Notice: organization %q, notices %v", asn1String(ref.Organization), ref.Numbers))
+			}
+			continue
+		}
+		out = append(out, fmt.Sprintf("  User Notice: %q", asn1String(p)))
+	}
+	return out
+}
+
+func decodePolicyMappings(v []byte) ([]string, error) {
+	var mappings []struct {
+		IssuerDomainPolicy  asn1.ObjectIdentifier
+		SubjectDomainPolicy asn1.ObjectIdentifier
+	}
+	if err := unmarshalAll(v, &mappings); err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, m := range mappings {
+		out = append(out, fmt.Sprintf("%s -> %s", FormatOID(m.IssuerDomainPolicy.String()), FormatOID(m.SubjectDomainPolicy.String())))
+	}
+	return out, nil
+}
+
+func decodePolicyConstraints(v []byte) ([]string, error) {
+	var pc struct {
+		RequireExplicitPolicy int `asn1:"optional,tag:0,default:-1"`
+		InhibitPolicyMapping  int `asn1:"optional,tag:1,default:-1"`
+	}
+	if err := unmarshalAll(v, &pc); err != nil {
+		return nil, err
+	}
+	var out []string
+	if pc.RequireExplicitPolicy >= 0 {
+		out = append(out, fmt.Sprintf("Require Explicit Policy: %d", pc.RequireExplicitPolicy))
+	}
+	if pc.InhibitPolicyMapping >= 0 {
+		out = append(out, fmt.Sprintf("Inhibit Policy Mapping: %d", pc.InhibitPolicyMapping))
+	}
+	return out, nil
+}
+
+func decodeInhibitAnyPolicy(v []byte) ([]string, error) {
+	var skip int
+	if err := unmarshalAll(v, &skip); err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("Skip Certs: %d", skip)}, nil
+}
+
+func decodeAuthorityKeyID(v []byte) ([]string, error) {
+	elems, err := sequenceElements(v)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, e := range elems {
+		switch e.Tag {
+		case 0:
+			out = append(out, "Key ID: "+hexColon(e.Bytes))
+		case 1:
+			gns, err := rawElements(e.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, "Issuer: "+strings.Join(generalNames(gns), ", "))
+		case 2:
+			out = append(out, "Serial: "+hexColon(e.Bytes))
+		}
+	}
+	return out, nil
+}
+
+func decodeInfoAccess(v []byte) ([]string, error) {
+	var ads []struct {
+		Method   asn1.ObjectIdentifier
+		Location asn1.RawValue
+	}
+	if err := unmarshalAll(v, &ads); err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, ad := range ads {
+		out = append(out, fmt.Sprintf("%s - %s", FormatOID(ad.Method.String()), generalName(ad.Location, false)))
+	}
+	return out, nil
+}
+
+func decodeTLSFeature(v []byte) ([]string, error) {
+	var features []int
+	if err := unmarshalAll(v, &features); err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, f := range features {
+		if s, ok := tlsFeatureNames[f]; ok {
+			out = append(out, s)
+		} else {
+			out = append(out, fmt.Sprintf("extension %d", f))
+		}
+	}
+	return out, nil
+}
+
+func decodeNull(v []byte) ([]string, error) {
+	var rv asn1.RawValue
+	if err := unmarshalAll(v, &rv); err != nil {
+		return nil, err
+	}
+	if rv.Tag != asn1.TagNull {
+		return nil, errors.New("expected NULL")
+	}
+	return []string{"present"}, nil
+}
+
+func decodeSCTList(v []byte) ([]string, error) {
+	scts, err := ParseSCTList(v)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for i, s := range scts {
+		out = append(out, fmt.Sprintf("SCT #%d: log %s, %s, %s",
+			i+1, base64.StdEncoding.EncodeToString(s.LogID), s.Timestamp.Format(time.RFC3339), s.SignatureName()))
+	}
+	return out, nil
+}
+
+func decodeDirectoryString(v []byte) ([]string, error) {
+	var rv asn1.RawValue
+	if err := unmarshalAll(v, &rv); err != nil {
+		return nil, err
+	}
+	return []string{asn1String(rv)}, nil
+}
+
+func decodeOctetString(v []byte) ([]string, error) {
+	var b []byte
+	if err := unmarshalAll(v, &b); err != nil {
+		return nil, err
+	}
+	return []string{hexColon(b)}, nil
+}
+
+// decodeMSCAVersion splits the CA version into certificate and key
+// indexes (the low and high 16 bits).
+func decodeMSCAVersion(v []byte) ([]string, error) {
+	var n int
+	if err := unmarshalAll(v, &n); err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("V%d.%d", n&0xffff, n>>16)}, nil
+}
+
+func decodeMSTemplate(v []byte) ([]string, error) {
+	var t struct {
+		Template     asn1.ObjectIdentifier
+		MajorVersion int `asn1:"optional,default:-1"`
+		MinorVersion int `asn1:"optional,default:-1"`
+	}
+	if err := unmarshalAll(v, &t); err != nil {
+		return nil, err
+	}
+	out := []string{"Template: " + FormatOID(t.Template.String())}
+	if t.MajorVersion >= 0 {
+		out = append(out, fmt.Sprintf("Major Version: %d", t.MajorVersion))
+	}
+	if t.MinorVersion >= 0 {
+		out = append(out, fmt.Sprintf("Minor Version: %d", t.MinorVersion))
+	}
+	return out, nil
+}
+
+func decodeMSApplicationPolicies(v []byte) ([]string, error) {
+	var policies []struct {
+		Policy asn1.ObjectIdentifier
+		Rest   asn1.RawValue `asn1:"optional"`
+	}
+	if err := unmarshalAll(v, &policies); err != nil {
+		return nil, err
+	}
+	var oids []asn1.ObjectIdentifier
+	for _, p := range policies {
+		oids = append(oids, p.Policy)
+	}
+	return []string{strings.Join(extKeyUsageOIDsToStrings(oids), ", ")}, nil
+}
@@ -0,0 +1,219 @@
+package pki
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// extensionByOID returns the decoded extension with the given OID
+func extensionByOID(t *testing.T, ci *CertInfo, oid string) Extension {
+	t.Helper()
+	for _, e := range ci.Extensions {
+		if e.OID == oid {
+			return e
+		}
+	}
+	t.Fatalf("Expected extension %s, got %+v", oid, ci.Extensions)
+	return Extension{}
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+	der, err := asn1.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	return der
+}
+
+// TestDecodeStandardExtensions tests the decoding of the RFC 5280 extensions
+func TestDecodeStandardExtensions(t *testing.T) {
+	root := newTestRoot(t, "Test Root")
+	_, ipnet, _ := net.ParseCIDR("10.0.0.0/8")
+	cert, _ := root.issue(t, &x509.Certificate{
+		Subject:                     pkix.Name{CommonName: "Test Sub CA"},
+		KeyUsage:                    x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid:       true,
+		IsCA:                        true,
+		MaxPathLenZero:              true,
+		PermittedDNSDomainsCritical: true,
+		PermittedDNSDomains:         []string{".example.com"},
+		ExcludedIPRanges:            []*net.IPNet{ipnet},
+	})
+
+	ci := GetCertInfo(cert)
+
+	bc := extensionByOID(t, ci, "2.5.29.19")
+	if bc.Name != "Basic Constraints" || !bc.Critical {
+		t.Errorf("Expected critical Basic Constraints, got %+v", bc)
+	}
+	if strings.Join(bc.Decoded, "\n") != "CA:TRUE, pathlen:0" {
+		t.Errorf("Expected 'CA:TRUE, pathlen:0', got %v", bc.Decoded)
+	}
+
+	nc := extensionByOID(t, ci, "2.5.29.30")
+	expected := []string{"Permitted: DNS:.example.com", "Excluded: IP:10.0.0.0/8"}
+	if strings.Join(nc.Decoded, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected name constraints %v, got %v", expected, nc.Decoded)
+	}
+
+	text := ci.Text()
+	if !strings.Contains(text, "  Name Constraints (2.5.29.30, critical):\n      Permitted: DNS:.example.com\n") {
+		t.Errorf("Expected name constraints in text output, got:\n%s", text)
+	}
+}
+
+// TestDecodeCertificatePolicies tests CPS and user notice qualifiers
+func TestDecodeCertificatePolicies(t *testing.T) {
+	type qualifier struct {
+		ID    asn1.ObjectIdentifier
+		Value asn1.RawValue
+	}
+	type policy struct {
+		ID         asn1.ObjectIdentifier
+		Qualifiers []qualifier `asn1:"optional"`
+	}
+	cps := mustMarshal(t, asn1.RawValue{Tag: asn1.TagIA5String, Bytes: []byte("https://cps.example.com")})
+	notice := mustMarshal(t, struct {
+		Text string `asn1:"utf8"`
+	}{"Use at own risk"})
+	value := mustMarshal(t, []policy{
+		{ID: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}, Qualifiers: []qualifier{
+			{ID: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 2, 1}, Value: asn1.RawValue{FullBytes: cps}},
+			{ID: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 2, 2}, Value: asn1.RawValue{FullBytes: notice}},
+		}},
+		{ID: asn1.ObjectIdentifier{2, 23, 140, 1, 2, 1}},
+	})
+
+	e := newExtension(pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 32}, Value: value})
+
+	expected := []string{
+		"Policy: 1.3.6.1.4.1.99999.1",
+		"  CPS: https://cps.example.com",
+		`  User Notice: "Use at own risk"`,
+		"Policy: CA/B Forum Domain Validated (2.23.140.1.2.1)",
+	}
+	if strings.Join(e.Decoded, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected %q, got %q", expected, e.Decoded)
+	}
+}
+
+// TestDecodeMiscExtensions tests policy, TLS feature and Microsoft extensions
+func TestDecodeMiscExtensions(t *testing.T) {
+	tests := []struct {
+		name     string
+		oid      asn1.ObjectIdentifier
+		value    []byte
+		expected []string
+	}{
+		{
+			name: "policy mappings",
+			oid:  asn1.ObjectIdentifier{2, 5, 29, 33},
+			value: mustMarshal(t, []struct{ Issuer, Subject asn1.ObjectIdentifier }{
+				{asn1.ObjectIdentifier{1, 2, 3, 4}, asn1.ObjectIdentifier{1, 2, 3, 5}},
+			}),
+			expected: []string{"1.2.3.4 -> 1.2.3.5"},
+		},
+		{
+			name:     "inhibit anyPolicy",
+			oid:      asn1.ObjectIdentifier{2, 5, 29, 54},
+			value:    mustMarshal(t, 3),
+			expected: []string{"Skip Certs: 3"},
+		},
+		{
+			name:     "must-staple",
+			oid:      asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24},
+			value:    mustMarshal(t, []int{5}),
+			expected: []string{"status_request (OCSP Must-Staple)"},
+		},
+		{
+			name:     "template name",
+			oid:      asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 20, 2},
+			value:    mustMarshal(t, asn1.RawValue{Tag: asn1.TagBMPString, Bytes: []byte{0, 'W', 0, 'e', 0, 'b'}}),
+			expected: []string{"Web"},
+		},
+		{
+			name: "template",
+			oid:  asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 21, 7},
+			value: mustMarshal(t, struct {
+				ID           asn1.ObjectIdentifier
+				Major, Minor int
+			}{asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 21, 8, 1}, 100, 4}),
+			expected: []string{"Template: 1.3.6.1.4.1.311.21.8.1", "Major Version: 100", "Minor Version: 4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newExtension(pkix.Extension{Id: tt.oid, Value: tt.value})
+			if e.Name == "" {
+				t.Errorf("Expected a name for %s", tt.oid)
+			}
+			if strings.Join(e.Decoded, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("Expected %q, got %q", tt.expected, e.Decoded)
+			}
+		})
+	}
+}
+
+// TestDecodeSCTListExtension tests rendering of an embedded SCT list
+func TestDecodeSCTListExtension(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	e := newExtension(pkix.Extension{
+		Id:    asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2},
+		Value: testSCTList(t, make([]byte, 32), ts, []byte{0xAB, 0xCD}),
+	})
+
+	if len(e.Decoded) != 1 || !strings.Contains(e.Decoded[0], "2024-05-01T12:00:00Z, ECDSA-SHA256") {
+		t.Errorf("Expected one decoded SCT, got %q", e.Decoded)
+	}
+}
+
+// TestDecodeUnknownExtension tests the ASN.1 dump fallback
+func TestDecodeUnknownExtension(t *testing.T) {
+	e := newExtension(pkix.Extension{
+		Id:       asn1.ObjectIdentifier{1, 2, 3, 4, 5},
+		Critical: true,
+		Value:    mustMarshal(t, struct{ N int }{42}),
+	})
+
+	if e.Name != "" {
+		t.Errorf("Expected no name for an unknown extension, got %q", e.Name)
+	}
+	expected := []string{"SEQUENCE", "  INTEGER 42"}
+	if strings.Join(e.Decoded, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected %q, got %q", expected, e.Decoded)
+	}
+	if h := e.heading(); h != "1.2.3.4.5 (critical):" {
+		t.Errorf("Expected heading '1.2.3.4.5 (critical):', got %q", h)
+	}
+
+	bad := newExtension(pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 19}, Value: []byte{0x01}})
+	if len(bad.Decoded) == 0 || !strings.HasPrefix(bad.Decoded[0], "(could not decode: ") {
+		t.Errorf("Expected decode error line, got %q", bad.Decoded)
+	}
+}
+
+// testSCTList builds an SCT list extension value holding one SCT from the
+// given log with an SHA256/ECDSA signature
+func testSCTList(t *testing.T, logID []byte, ts time.Time, sig []byte) []byte {
+	t.Helper()
+	sct := []byte{0}
+	sct = append(sct, logID...)
+	sct = binary.BigEndian.AppendUint64(sct, uint64(ts.UnixMilli()))
+	sct = append(sct, 0, 0) // no extensions
+	sct = append(sct, 4, 3)
+	sct = binary.BigEndian.AppendUint16(sct, uint16(len(sig)))
+	sct = append(sct, sig...)
+
+	list := binary.BigEndian.AppendUint16(nil, uint16(len(sct)))
+	list = append(list, sct...)
+	list = append(binary.BigEndian.AppendUint16(nil, uint16(len(list))), list...)
+	return mustMarshal(t, list)
+}
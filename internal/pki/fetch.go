@@ -0,0 +1,119 @@
+package pki
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"time"
+)
+
+// FetchOptions configures FetchTLS.
+type FetchOptions struct {
+	// ServerName is sent as SNI and used for hostname verification. It
+	// defaults to the host part of the address.
+	ServerName string
+	ALPN       []string
+	ClientCert *tls.Certificate
+	// Roots replaces the system trust store for verification.
+	Roots   []*x509.Certificate
+	Timeout time.Duration
+}
+
+// TLSInfo describes what a TLS server presented during the handshake.
+type TLSInfo struct {
+	Address      string
+	ServerName   string
+	Version      string
+	CipherSuite  string
+	ALPN         string
+	Certificates []*x509.Certificate
+	// OCSPResponse is the raw stapled OCSP response, if any. OCSP holds it
+	// parsed and verified against the leaf's issuer, or OCSPErr says why
+	// that failed.
+	OCSPResponse []byte
+	OCSP         *OCSPInfo
+	OCSPErr      error
+	Verify       *VerifyResult
+}
+
+// FetchTLS performs a TLS handshake with addr (host:port) and returns the
+// negotiated parameters and the presented chain, verified against
+// opts.Roots or the system roots.
+func FetchTLS(addr string, opts FetchOptions) (*TLSInfo, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	serverName := opts.ServerName
+	if serverName == "" {
+		serverName = host
+	}
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	cfg := &tls.Config{
+		ServerName: serverName,
+		NextProtos: opts.ALPN,
+		// The chain is verified below so that failures are reported rather
+		// than aborting the handshake.
+		InsecureSkipVerify: true, // #nosec G402
+	}
+	if opts.ClientCert != nil {
+		cfg.Certificates = []tls.Certificate{*opts.ClientCert}
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", addr, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	state := conn.ConnectionState()
+	info := &TLSInfo{
+		Address:      addr,
+		ServerName:   serverName,
+		Version:      tls.VersionName(state.Version),
+		CipherSuite:  tls.CipherSuiteName(state.CipherSuite),
+		ALPN:         state.NegotiatedProtocol,
+		Certificates: state.PeerCertificates,
+		OCSPResponse: state.OCSPResponse,
+	}
+	if len(state.PeerCertificates) == 0 {
+		return info, errors.New("server presented no certificates")
+	}
+	info.Verify = VerifyChain(state.PeerCertificates[0], VerifyOptions{
+		Roots:         opts.Roots,
+		Intermediates: state.PeerCertificates[1:],
+		Host:          serverName,
+	})
+
+	if len(info.OCSPResponse) > 0 {
+		if issuer := leafIssuer(info.Verify); issuer != nil {
+			info.OCSP, info.OCSPErr = ParseOCSPResponse(info.OCSPResponse, info.Verify.Leaf, issuer)
+		} else {
+			info.OCSPErr = errors.New("issuer certificate not available")
+		}
+	}
+	return info, nil
+}
+
+// leafIssuer returns the leaf's issuer from the first path that has one,
+// preferring verified paths.
+func leafIssuer(res *VerifyResult) *x509.Certificate {
+	var fallback *x509.Certificate
+	for _, p := range res.Paths {
+		if len(p.Certs) < 2 {
+			continue
+		}
+		if p.Err == nil {
+			return p.Certs[1]
+		}
+		if fallback == nil {
+			fallback = p.Certs[1]
+		}
+	}
+	return fallback
+}
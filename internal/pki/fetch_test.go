@@ -0,0 +1,89 @@
+package pki
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestFetchTLS tests inspecting a local httptest TLS server
+func TestFetchTLS(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{NextProtos: []string{"h2", "http/1.1"}}
+	ts.StartTLS()
+	defer ts.Close()
+
+	addr := strings.TrimPrefix(ts.URL, "https://")
+
+	info, err := FetchTLS(addr, FetchOptions{
+		ServerName: "example.com",
+		ALPN:       []string{"http/1.1"},
+		Roots:      []*x509.Certificate{ts.Certificate()},
+	})
+	if err != nil {
+		t.Fatalf("FetchTLS failed: %v", err)
+	}
+
+	if len(info.Certificates) == 0 {
+		t.Fatal("Expected peer certificates")
+	}
+	if info.ServerName != "example.com" {
+		t.Errorf("Expected server name 'example.com', got '%s'", info.ServerName)
+	}
+	if info.ALPN != "http/1.1" {
+		t.Errorf("Expected ALPN 'http/1.1', got '%s'", info.ALPN)
+	}
+	if info.Version == "" || info.CipherSuite == "" {
+		t.Errorf("Expected negotiated version and cipher, got %q %q", info.Version, info.CipherSuite)
+	}
+	if !info.Verify.OK() {
+		t.Errorf("Expected verification to succeed, got err=%v hostErr=%v", info.Verify.Err, info.Verify.HostErr)
+	}
+}
+
+// TestFetchTLSUntrusted tests that verification failures are reported, not fatal
+func TestFetchTLSUntrusted(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	root := newTestRoot(t, "Unrelated Root")
+	info, err := FetchTLS(strings.TrimPrefix(ts.URL, "https://"), FetchOptions{
+		ServerName: "example.com",
+		Roots:      []*x509.Certificate{root.cert},
+	})
+	if err != nil {
+		t.Fatalf("FetchTLS failed: %v", err)
+	}
+	if info.Verify.OK() {
+		t.Error("Expected verification against an unrelated root to fail")
+	}
+}
+
+// TestFetchTLSHostMismatch tests that a wrong server name is reported as a
+// host mismatch while the chain itself verifies
+func TestFetchTLSHostMismatch(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	info, err := FetchTLS(strings.TrimPrefix(ts.URL, "https://"), FetchOptions{
+		ServerName: "other.test",
+		Roots:      []*x509.Certificate{ts.Certificate()},
+	})
+	if err != nil {
+		t.Fatalf("FetchTLS failed: %v", err)
+	}
+	if info.Verify.HostErr == nil {
+		t.Error("Expected a host mismatch for other.test")
+	}
+	if info.Verify.Err != nil {
+		t.Errorf("Expected the chain to verify, got %v", info.Verify.Err)
+	}
+	for i, p := range info.Verify.Paths {
+		if p.Err != nil {
+			t.Errorf("Expected path %d to verify, got %v", i+1, p.Err)
+		}
+	}
+}
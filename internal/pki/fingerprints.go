@@ -0,0 +1,178 @@
+package pki
+
+import (
+	"crypto/sha1" // #nosec G505 -- SHA-1 fingerprints and key IDs are identifiers, not signatures
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Fingerprint algorithms selectable for the text output. FingerprintSPKI
+// is the RFC 7469 pin and FingerprintKeyIDs the computed key identifiers.
+const (
+	FingerprintSHA1   = "sha1"
+	FingerprintSHA256 = "sha256"
+	FingerprintSHA384 = "sha384"
+	FingerprintSHA512 = "sha512"
+	FingerprintSPKI   = "spki"
+	FingerprintKeyIDs = "ski"
+)
+
+// FingerprintAlgorithms lists every selectable algorithm, in output order.
+var FingerprintAlgorithms = []string{
+	FingerprintSHA1, FingerprintSHA256, FingerprintSHA384, FingerprintSHA512, FingerprintSPKI, FingerprintKeyIDs,
+}
+
+// KeyID is a subject key identifier computed from a public key by one of
+// the methods of RFC 5280, section 4.2.1.2, or RFC 7093.
+type KeyID struct {
+	Method string `json:"method" yaml:"method"`
+	Value  string `json:"value" yaml:"value"`
+}
+
+// fingerprintsOf computes the digests of c and of its public key.
+func fingerprintsOf(c *x509.Certificate) Fingerprint {
+	sum1 := sha1.Sum(c.Raw) // #nosec G401
+	sum256 := sha256.Sum256(c.Raw)
+	sum384 := sha512.Sum384(c.Raw)
+	sum512 := sha512.Sum512(c.Raw)
+	fp := Fingerprint{
+		SHA1:       hexColon(sum1[:]),
+		SHA256:     hexColon(sum256[:]),
+		SHA384:     hexColon(sum384[:]),
+		SHA512:     hexColon(sum512[:]),
+		SPKISHA256: SPKIPin(c),
+		KeyIDs:     computeKeyIDs(c.RawSubjectPublicKeyInfo),
+	}
+	if len(c.SubjectKeyId) > 0 {
+		for _, id := range fp.KeyIDs {
+			if id.Value == hexColon(c.SubjectKeyId) {
+				fp.SubjectKeyIDMethod = id.Method
+				break
+			}
+		}
+	}
+	return fp
+}
+
+// computeKeyIDs derives the key identifiers of a DER SubjectPublicKeyInfo.
+// The RFC 5280 and RFC 7093 method 1-3 identifiers hash the
+// subjectPublicKey bits; method 4 hashes the whole SubjectPublicKeyInfo.
+func computeKeyIDs(spki []byte) []KeyID {
+	var info struct {
+		Algorithm asn1.RawValue
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(spki, &info); err != nil {
+		return nil
+	}
+	key := info.PublicKey.Bytes
+	sum1 := sha1.Sum(key) // #nosec G401
+	sum256 := sha256.Sum256(key)
+	sum384 := sha512.Sum384(key)
+	sum512 := sha512.Sum512(key)
+	spkiSum := sha256.Sum256(spki)
+	return []KeyID{
+		{Method: "RFC 5280 (SHA-1)", Value: hexColon(sum1[:])},
+		{Method: "RFC 7093 method 1 (SHA-256, 160 bits)", Value: hexColon(sum256[:20])},
+		{Method: "RFC 7093 method 2 (SHA-384, 160 bits)", Value: hexColon(sum384[:20])},
+		{Method: "RFC 7093 method 3 (SHA-512, 160 bits)", Value: hexColon(sum512[:20])},
+		{Method: "RFC 7093 method 4 (SHA-256 of SPKI)", Value: hexColon(spkiSum[:])},
+	}
+}
+
+// SPKIPin returns the base64 SHA-256 of c's SubjectPublicKeyInfo, the
+// pin-sha256 value of RFC 7469.
+func SPKIPin(c *x509.Certificate) string {
+	return pinOf(c.RawSubjectPublicKeyInfo)
+}
+
+// ParsePin normalizes a SHA-256 SPKI pin given as an HPKP directive
+// (pin-sha256="..."), with a "sha256/" prefix, or as bare base64 or hex,
+// to base64.
+func ParsePin(s string) (string, error) {
+	p := strings.TrimSpace(s)
+	p = strings.TrimSuffix(strings.TrimPrefix(p, "pin-sha256="), ";")
+	p = strings.Trim(p, `"`)
+	p = strings.TrimPrefix(p, "sha256/")
+
+	sum, err := base64.StdEncoding.DecodeString(p)
+	if err != nil || len(sum) != sha256.Size {
+		if h, herr := hex.DecodeString(strings.ReplaceAll(p, ":", "")); herr == nil && len(h) == sha256.Size {
+			sum = h
+		} else {
+			return "", fmt.Errorf("invalid pin %q: expected a base64 or hex SHA-256 digest", s)
+		}
+	}
+	return base64.StdEncoding.EncodeToString(sum), nil
+}
+
+// PinMatch reports whether one certificate of a chain matched a pin set.
+type PinMatch struct {
+	Index   int    `json:"index" yaml:"index"`
+	Subject string `json:"subject" yaml:"subject"`
+	Pin     string `json:"pin" yaml:"pin"`
+	Matched bool   `json:"matched" yaml:"matched"`
+}
+
+// MatchPins checks each certificate of chain against pins, which must be
+// normalized by ParsePin. As with HPKP, the chain matches when any of its
+// certificates does.
+func MatchPins(chain []*x509.Certificate, pins []string) ([]PinMatch, bool) {
+	set := make(map[string]bool, len(pins))
+	for _, p := range pins {
+		set[p] = true
+	}
+	var out []PinMatch
+	matched := false
+	for i, c := range chain {
+		m := PinMatch{Index: i + 1, Subject: FormatName(c.Subject), Pin: SPKIPin(c)}
+		m.Matched = set[m.Pin]
+		matched = matched || m.Matched
+		out = append(out, m)
+	}
+	return out, matched
+}
+
+// fingerprintText renders the fingerprint lines for the selected
+// algorithms.
+func fingerprintText(fp Fingerprint, algs []string) string {
+	var buf strings.Builder
+
+	want := map[string]bool{}
+	for _, a := range algs {
+		want[a] = true
+	}
+	if want[FingerprintSHA1] {
+		fmt.Fprintf(&buf, "Fingerprint SHA-1:   %s\n", fp.SHA1)
+	}
+	if want[FingerprintSHA256] {
+		fmt.Fprintf(&buf, "Fingerprint SHA-256: %s\n", fp.SHA256)
+	}
+	if want[FingerprintSHA384] {
+		fmt.Fprintf(&buf, "Fingerprint SHA-384: %s\n", fp.SHA384)
+	}
+	if want[FingerprintSHA512] {
+		fmt.Fprintf(&buf, "Fingerprint SHA-512: %s\n", fp.SHA512)
+	}
+	if want[FingerprintSPKI] {
+		fmt.Fprintf(&buf, "SPKI Pin SHA-256:    %s\n", fp.SPKISHA256)
+	}
+	if want[FingerprintKeyIDs] && len(fp.KeyIDs) > 0 {
+		fmt.Fprintf(&buf, "Computed Key IDs:\n")
+		for _, id := range fp.KeyIDs {
+			mark := ""
+			if id.Method == fp.SubjectKeyIDMethod {
+				mark = " (matches Subject Key ID)"
+			}
+			fmt.Fprintf(&buf, "  %s:%s\n      %s\n", id.Method, mark, id.Value)
+		}
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,105 @@
+package pki
+
+import (
+	"crypto/sha1" // #nosec G505 -- compared against the fingerprint under test
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// TestFingerprints tests the certificate digests, pin and key IDs
+func TestFingerprints(t *testing.T) {
+	root := newTestRoot(t, "Test Root")
+	fp := GetCertInfo(root.cert).Fingerprints
+
+	sum1 := sha1.Sum(root.cert.Raw) // #nosec G401
+	if fp.SHA1 != hexColon(sum1[:]) {
+		t.Errorf("Expected SHA-1 %s, got %s", hexColon(sum1[:]), fp.SHA1)
+	}
+	if len(fp.SHA384) != 48*3-1 || len(fp.SHA512) != 64*3-1 {
+		t.Errorf("Unexpected SHA-384/512 lengths: %s, %s", fp.SHA384, fp.SHA512)
+	}
+
+	spki := sha256.Sum256(root.cert.RawSubjectPublicKeyInfo)
+	if fp.SPKISHA256 != base64.StdEncoding.EncodeToString(spki[:]) {
+		t.Errorf("Unexpected SPKI pin %s", fp.SPKISHA256)
+	}
+
+	if len(fp.KeyIDs) != 5 {
+		t.Fatalf("Expected 5 key IDs, got %+v", fp.KeyIDs)
+	}
+	if fp.KeyIDs[4].Value != hexColon(spki[:]) {
+		t.Errorf("Expected method 4 to hash the SPKI, got %s", fp.KeyIDs[4].Value)
+	}
+	// crypto/x509 fills in the Subject Key ID with one of these methods.
+	if fp.SubjectKeyIDMethod == "" {
+		t.Errorf("Expected a method to match SKI %s, got %+v", hexColon(root.cert.SubjectKeyId), fp.KeyIDs)
+	}
+}
+
+// TestTextWithFingerprints tests the fingerprint selection of the text output
+func TestTextWithFingerprints(t *testing.T) {
+	ci := GetCertInfo(createTestCertificate(t))
+
+	if text := ci.Text(); !strings.Contains(text, "Fingerprint SHA-256: ") || strings.Contains(text, "SHA-1") {
+		t.Errorf("Expected only the SHA-256 fingerprint by default, got:\n%s", text)
+	}
+	text := ci.TextWithFingerprints([]string{FingerprintSHA1, FingerprintSPKI})
+	if !strings.Contains(text, "Fingerprint SHA-1:   "+ci.Fingerprints.SHA1+"\n") {
+		t.Errorf("Expected SHA-1 fingerprint, got:\n%s", text)
+	}
+	if !strings.Contains(text, "SPKI Pin SHA-256:    "+ci.Fingerprints.SPKISHA256+"\n") {
+		t.Errorf("Expected SPKI pin, got:\n%s", text)
+	}
+	if strings.Contains(text, "Fingerprint SHA-256") {
+		t.Errorf("Expected no SHA-256 fingerprint, got:\n%s", text)
+	}
+}
+
+// TestParsePin tests the accepted pin notations
+func TestParsePin(t *testing.T) {
+	sum := sha256.Sum256([]byte("key"))
+	b64 := base64.StdEncoding.EncodeToString(sum[:])
+
+	for _, in := range []string{
+		b64,
+		"sha256/" + b64,
+		`pin-sha256="` + b64 + `";`,
+		hexColon(sum[:]),
+	} {
+		got, err := ParsePin(in)
+		if err != nil {
+			t.Errorf("ParsePin(%q) failed: %v", in, err)
+		} else if got != b64 {
+			t.Errorf("ParsePin(%q) = %s, expected %s", in, got, b64)
+		}
+	}
+
+	for _, in := range []string{"", "sha256/abc", base64.StdEncoding.EncodeToString(sum[:20])} {
+		if _, err := ParsePin(in); err == nil {
+			t.Errorf("Expected error for pin %q", in)
+		}
+	}
+}
+
+// TestMatchPins tests that a chain matches when any certificate does
+func TestMatchPins(t *testing.T) {
+	root := newTestRoot(t, "Test Root")
+	inter := root.intermediate(t, "Test Intermediate")
+	leaf := inter.leaf(t, "www.example.com")
+	chain := []*x509.Certificate{leaf, inter.cert}
+
+	results, matched := MatchPins(chain, []string{SPKIPin(inter.cert)})
+	if !matched {
+		t.Error("Expected the chain to match the intermediate pin")
+	}
+	if results[0].Matched || !results[1].Matched {
+		t.Errorf("Expected only #2 to match, got %+v", results)
+	}
+
+	if _, matched := MatchPins(chain, []string{SPKIPin(root.cert)}); matched {
+		t.Error("Expected no match for a pin of a certificate outside the chain")
+	}
+}
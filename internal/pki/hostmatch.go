@@ -0,0 +1,223 @@
+package pki
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// HostMatch is the outcome of checking one host against a certificate.
+type HostMatch struct {
+	Host string `json:"host" yaml:"host"`
+	// Normalized is the host as compared: lower case, without a trailing
+	// dot, and with internationalized labels as punycode A-labels.
+	Normalized string `json:"normalized" yaml:"normalized"`
+	IP         bool   `json:"ip" yaml:"ip"`
+	Matched    bool   `json:"matched" yaml:"matched"`
+	// SAN is the subject alternative name that matched, as "DNS:..." or
+	// "IP:...".
+	SAN string `json:"san,omitempty" yaml:"san,omitempty"`
+	// Reasons explains a mismatch: names that came close and why they do
+	// not count, or that there was nothing to match against.
+	Reasons []string `json:"reasons,omitempty" yaml:"reasons,omitempty"`
+	// ConstraintViolations lists the name constraints of the issuers that
+	// forbid the host.
+	ConstraintViolations []string `json:"constraint_violations,omitempty" yaml:"constraint_violations,omitempty"`
+}
+
+// OK reports whether the host matched and no name constraint forbids it.
+func (m HostMatch) OK() bool {
+	return m.Matched && len(m.ConstraintViolations) == 0
+}
+
+// HostCheckResult reports CheckHosts for every host.
+type HostCheckResult struct {
+	Subject     string   `json:"subject" yaml:"subject"`
+	DNSNames    []string `json:"dns_names,omitempty" yaml:"dns_names,omitempty"`
+	IPAddresses []string `json:"ip_addresses,omitempty" yaml:"ip_addresses,omitempty"`
+	// Issuers are the certificates whose name constraints were applied,
+	// nearest first.
+	Issuers []string    `json:"issuers,omitempty" yaml:"issuers,omitempty"`
+	Hosts   []HostMatch `json:"hosts" yaml:"hosts"`
+}
+
+// OK reports whether every host was OK.
+func (r *HostCheckResult) OK() bool {
+	for _, h := range r.Hosts {
+		if !h.OK() {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHosts decides whether each of hosts matches a subject alternative
+// name of leaf under the RFC 6125 rules TLS clients apply: DNS names
+// compare case-insensitively after IDNA conversion, a wildcard is only
+// accepted as the whole left-most label and stands for exactly one label,
+// IP addresses only match IP SANs, and the common name is ignored. The
+// name constraints of leaf's issuers among chain are checked as well.
+func CheckHosts(leaf *x509.Certificate, chain []*x509.Certificate, hosts []string) *HostCheckResult {
+	res := &HostCheckResult{
+		Subject:  FormatName(leaf.Subject),
+		DNSNames: leaf.DNSNames,
+	}
+	for _, ip := range leaf.IPAddresses {
+		res.IPAddresses = append(res.IPAddresses, ip.String())
+	}
+	issuers := issuerChain(leaf, chain)
+	for _, c := range issuers {
+		res.Issuers = append(res.Issuers, FormatName(c.Subject))
+	}
+	for _, host := range hosts {
+		res.Hosts = append(res.Hosts, checkHost(leaf, issuers, host))
+	}
+	return res
+}
+
+func checkHost(leaf *x509.Certificate, issuers []*x509.Certificate, host string) HostMatch {
+	m := HostMatch{Host: host}
+
+	if ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")); ip != nil {
+		m.IP = true
+		m.Normalized = ip.String()
+		for _, san := range leaf.IPAddresses {
+			if san.Equal(ip) {
+				m.Matched = true
+				m.SAN = "IP:" + san.String()
+				break
+			}
+		}
+		if !m.Matched {
+			for _, name := range leaf.DNSNames {
+				if name == host || name == m.Normalized {
+					m.Reasons = append(m.Reasons, "DNS:"+name+" is a DNS name; IP addresses only match IP SANs")
+				}
+			}
+			if len(leaf.IPAddresses) == 0 {
+				m.Reasons = append(m.Reasons, "the certificate has no IP address SANs")
+			} else if len(m.Reasons) == 0 {
+				m.Reasons = append(m.Reasons, "no IP address SAN is "+m.Normalized)
+			}
+		}
+		m.ConstraintViolations = sanViolations(issuers, "IP:"+ip.String())
+		return m
+	}
+
+	name, err := hostnameToASCII(host)
+	if err != nil {
+		m.Reasons = append(m.Reasons, "invalid hostname: "+err.Error())
+		return m
+	}
+	m.Normalized = name
+	for _, san := range leaf.DNSNames {
+		ok, reason := matchHostname(san, name)
+		if ok {
+			m.Matched = true
+			m.SAN = "DNS:" + san
+			m.Reasons = nil
+			break
+		}
+		if reason != "" {
+			m.Reasons = append(m.Reasons, "DNS:"+san+": "+reason)
+		}
+	}
+	if !m.Matched && len(m.Reasons) == 0 {
+		switch {
+		case len(leaf.DNSNames) > 0:
+			m.Reasons = append(m.Reasons, "no DNS name SAN covers "+name)
+		case strings.EqualFold(strings.TrimSuffix(leaf.Subject.CommonName, "."), name):
+			m.Reasons = append(m.Reasons, "only the common name matches, and clients no longer check it (RFC 6125, section 6.4.4)")
+		default:
+			m.Reasons = append(m.Reasons, "the certificate has no DNS name SANs")
+		}
+	}
+	m.ConstraintViolations = sanViolations(issuers, "DNS:"+name)
+	return m
+}
+
+// matchHostname reports whether the DNS SAN pattern matches host, which must
+// be normalized. When it does not but the pattern comes close, the reason
+// says why; otherwise the reason is empty.
+func matchHostname(pattern, host string) (bool, string) {
+	pattern = strings.TrimSuffix(strings.ToLower(pattern), ".")
+	if pattern == host {
+		return true, ""
+	}
+	if !strings.Contains(pattern, "*") {
+		return false, ""
+	}
+
+	patternLabels := strings.Split(pattern, ".")
+	hostLabels := strings.Split(host, ".")
+	first, rest := patternLabels[0], patternLabels[1:]
+	suffix := strings.Join(rest, ".")
+	if strings.Contains(suffix, "*") {
+		if len(hostLabels) == len(patternLabels) {
+			return false, "a wildcard is only allowed in the left-most label"
+		}
+		return false, ""
+	}
+	if host == suffix {
+		return false, "a wildcard does not match the bare domain " + suffix
+	}
+	if !strings.HasSuffix(host, "."+suffix) {
+		return false, ""
+	}
+	if len(hostLabels) != len(patternLabels) {
+		extra := strings.Join(hostLabels[:len(hostLabels)-len(rest)], ".")
+		return false, fmt.Sprintf("a wildcard matches exactly one label, not %q", extra)
+	}
+	if len(rest) < 2 {
+		return false, "a wildcard may not cover a whole top-level domain"
+	}
+	if first != "*" {
+		before, after, _ := strings.Cut(first, "*")
+		if label := hostLabels[0]; !strings.HasPrefix(label, before) || !strings.HasSuffix(label, after) {
+			return false, ""
+		}
+		return false, "partial wildcards such as " + first + " are not accepted (RFC 6125, section 6.4.3; CA/Browser Forum Baseline Requirements)"
+	}
+	return true, ""
+}
+
+// Text renders r with one entry per host.
+func (r *HostCheckResult) Text() string {
+	var buf strings.Builder
+
+	fmt.Fprintf(&buf, "Certificate:         %s\n", r.Subject)
+	dnsNames := make([]string, len(r.DNSNames))
+	for i, name := range r.DNSNames {
+		dnsNames[i] = name
+		if u := hostnameToUnicode(name); u != name {
+			dnsNames[i] += " (" + u + ")"
+		}
+	}
+	fmt.Fprintf(&buf, "DNS Names:           %s\n", JoinOrNone(dnsNames))
+	fmt.Fprintf(&buf, "IP Addresses:        %s\n", JoinOrNone(r.IPAddresses))
+	fmt.Fprintf(&buf, "Issuers Checked:     %s\n", JoinOrNone(r.Issuers))
+	for _, h := range r.Hosts {
+		var status string
+		switch {
+		case h.OK():
+			status = "OK, matches " + h.SAN
+		case h.Matched:
+			status = "REJECTED, matches " + h.SAN + " but violates name constraints"
+		default:
+			status = "MISMATCH"
+		}
+		fmt.Fprintf(&buf, "Host:                %s: %s\n", h.Host, status)
+		if h.Normalized != "" && h.Normalized != h.Host {
+			fmt.Fprintf(&buf, "  Normalized:        %s\n", h.Normalized)
+		}
+		for _, reason := range h.Reasons {
+			fmt.Fprintf(&buf, "  Reason:            %s\n", reason)
+		}
+		for _, v := range h.ConstraintViolations {
+			fmt.Fprintf(&buf, "  Name Constraint:   %s\n", v)
+		}
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,114 @@
+package pki
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"strings"
+	"testing"
+)
+
+// TestMatchHostname tests the RFC 6125 wildcard rules and their reasons
+func TestMatchHostname(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    string
+		match   bool
+		reason  string
+	}{
+		{"www.example.com", "www.example.com", true, ""},
+		{"WWW.Example.com.", "www.example.com", true, ""},
+		{"*.example.com", "www.example.com", true, ""},
+		{"*.example.com", "a.b.example.com", false, "exactly one label"},
+		{"*.example.com", "example.com", false, "bare domain"},
+		{"*.example.com", "www.example.org", false, ""},
+		{"www.*.com", "www.example.com", false, "left-most label"},
+		{"*.com", "example.com", false, "top-level domain"},
+		{"f*.example.com", "foo.example.com", false, "partial wildcard"},
+		{"f*.example.com", "bar.example.com", false, ""},
+	}
+	for _, tt := range tests {
+		match, reason := matchHostname(tt.pattern, tt.host)
+		if match != tt.match {
+			t.Errorf("Expected %s against %s to match=%t, got %t", tt.pattern, tt.host, tt.match, match)
+		}
+		if (tt.reason == "") != (reason == "") || !strings.Contains(reason, tt.reason) {
+			t.Errorf("Expected %s against %s to give a reason containing %q, got %q", tt.pattern, tt.host, tt.reason, reason)
+		}
+	}
+}
+
+// TestCheckHosts tests IDN, IP and name-constraint checks through an
+// intermediate
+func TestCheckHosts(t *testing.T) {
+	root := newTestRoot(t, "Host Root")
+	_, permitted, _ := net.ParseCIDR("10.0.0.0/8")
+	interCert, interKey := root.issue(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Constrained Intermediate"},
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		PermittedDNSDomains:   []string{"example.com", "xn--bcher-kva.example"},
+		ExcludedDNSDomains:    []string{"bad.example.com"},
+		PermittedIPRanges:     []*net.IPNet{permitted},
+	})
+	inter := &testIssuer{cert: interCert, key: interKey}
+	leaf, _ := inter.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "host.example.com"},
+		DNSNames:    []string{"*.example.com", "xn--bcher-kva.example", "10.0.0.2"},
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("192.168.0.1")},
+	})
+
+	res := CheckHosts(leaf, []*x509.Certificate{root.cert, inter.cert}, []string{
+		"www.example.com", "Bücher.example", "bad.example.com", "10.0.0.1", "192.168.0.1", "10.0.0.2",
+	})
+	if len(res.Issuers) != 2 || res.Issuers[0] != "CN=Constrained Intermediate" {
+		t.Errorf("Expected both issuers nearest first, got %v", res.Issuers)
+	}
+	want := []struct {
+		ok, matched bool
+		san         string
+	}{
+		{true, true, "DNS:*.example.com"},
+		{true, true, "DNS:xn--bcher-kva.example"},
+		{false, true, "DNS:*.example.com"},
+		{true, true, "IP:10.0.0.1"},
+		{false, true, "IP:192.168.0.1"},
+		{false, false, ""},
+	}
+	for i, w := range want {
+		h := res.Hosts[i]
+		if h.OK() != w.ok || h.Matched != w.matched || h.SAN != w.san {
+			t.Errorf("Expected %s to be ok=%t matched=%t via %q, got %+v", h.Host, w.ok, w.matched, w.san, h)
+		}
+	}
+	if h := res.Hosts[1]; h.Normalized != "xn--bcher-kva.example" {
+		t.Errorf("Expected the IDN to be normalized to its A-label, got %q", h.Normalized)
+	}
+	if h := res.Hosts[2]; len(h.ConstraintViolations) != 1 || !strings.Contains(h.ConstraintViolations[0], "excluded by CN=Constrained Intermediate") {
+		t.Errorf("Expected bad.example.com to be excluded, got %v", h.ConstraintViolations)
+	}
+	if h := res.Hosts[5]; len(h.Reasons) != 1 || !strings.Contains(h.Reasons[0], "only match IP SANs") {
+		t.Errorf("Expected a DNS SAN holding an IP to be explained, got %v", h.Reasons)
+	}
+	if res.OK() {
+		t.Error("Expected the result not to be OK")
+	}
+	text := res.Text()
+	for _, s := range []string{"xn--bcher-kva.example (bücher.example)", "Host:                www.example.com: OK, matches DNS:*.example.com", "REJECTED"} {
+		if !strings.Contains(text, s) {
+			t.Errorf("Expected text to contain '%s':\n%s", s, text)
+		}
+	}
+}
+
+// TestCheckHostsCommonName tests that a common name alone is not accepted
+func TestCheckHostsCommonName(t *testing.T) {
+	root := newTestRoot(t, "Host Root")
+	leaf, _ := root.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "legacy.example.com"}})
+
+	h := CheckHosts(leaf, nil, []string{"legacy.example.com"}).Hosts[0]
+	if h.Matched || len(h.Reasons) != 1 || !strings.Contains(h.Reasons[0], "common name") {
+		t.Errorf("Expected the common name to be ignored with a reason, got %+v", h)
+	}
+}
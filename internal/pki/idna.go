@@ -0,0 +1,243 @@
+package pki
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Punycode parameters from RFC 3492, section 5.
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+	// punyMaxValue bounds intermediate values while decoding; no valid
+	// label of 63 bytes comes near it.
+	punyMaxValue = 1 << 30
+)
+
+// acePrefix marks a punycode-encoded label (an IDNA A-label).
+const acePrefix = "xn--"
+
+// hostnameToASCII converts a hostname to the form certificates carry it in:
+// lower case, without a trailing dot, and with every label that is not
+// ASCII punycode-encoded as an A-label. Unicode labels are lower-cased but
+// not otherwise mapped, so it covers ordinary IDNs rather than all of UTS
+// #46.
+func hostnameToASCII(host string) (string, error) {
+	host = strings.TrimSuffix(host, ".")
+	if host == "" {
+		return "", errors.New("empty hostname")
+	}
+	labels := strings.Split(host, ".")
+	for i, label := range labels {
+		if label == "" {
+			return "", errors.New("empty label")
+		}
+		if !utf8.ValidString(label) {
+			return "", fmt.Errorf("label %q is not valid UTF-8", label)
+		}
+		label = strings.ToLower(label)
+		if isASCII(label) {
+			if strings.HasPrefix(label, acePrefix) {
+				if _, err := punycodeDecode(label[len(acePrefix):]); err != nil {
+					return "", fmt.Errorf("label %q: %w", label, err)
+				}
+			}
+		} else {
+			enc, err := punycodeEncode(label)
+			if err != nil {
+				return "", fmt.Errorf("label %q: %w", label, err)
+			}
+			label = acePrefix + enc
+		}
+		if len(label) > 63 {
+			return "", fmt.Errorf("label %q is longer than 63 bytes", label)
+		}
+		labels[i] = label
+	}
+	host = strings.Join(labels, ".")
+	if len(host) > 253 {
+		return "", errors.New("hostname is longer than 253 bytes")
+	}
+	return host, nil
+}
+
+// hostnameToUnicode decodes the A-labels of host for display, leaving any
+// that are not valid punycode as they are.
+func hostnameToUnicode(host string) string {
+	labels := strings.Split(host, ".")
+	for i, label := range labels {
+		if !strings.HasPrefix(strings.ToLower(label), acePrefix) {
+			continue
+		}
+		if dec, err := punycodeDecode(label[len(acePrefix):]); err == nil {
+			labels[i] = dec
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// punycodeEncode encodes a Unicode label without the ACE prefix (RFC 3492,
+// section 6.3).
+func punycodeEncode(s string) (string, error) {
+	runes := []rune(s)
+	var out []byte
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+		}
+	}
+	basic := len(out)
+	handled := basic
+	if basic > 0 {
+		out = append(out, '-')
+	}
+
+	n, delta, bias := punyInitialN, 0, punyInitialBias
+	for handled < len(runes) {
+		m := int(utf8.MaxRune) + 1
+		for _, r := range runes {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+		if (m-n)*(handled+1) > punyMaxValue-delta {
+			return "", errors.New("punycode overflow")
+		}
+		delta += (m - n) * (handled + 1)
+		n = m
+		for _, r := range runes {
+			if int(r) < n {
+				delta++
+			}
+			if int(r) != n {
+				continue
+			}
+			q := delta
+			for k := punyBase; ; k += punyBase {
+				t := punyThreshold(k, bias)
+				if q < t {
+					break
+				}
+				out = append(out, punyDigit(t+(q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			out = append(out, punyDigit(q))
+			bias = punyAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return string(out), nil
+}
+
+// punycodeDecode decodes a label without the ACE prefix (RFC 3492, section
+// 6.2).
+func punycodeDecode(s string) (string, error) {
+	var output []rune
+	pos := 0
+	if i := strings.LastIndexByte(s, '-'); i >= 0 {
+		if !isASCII(s[:i]) {
+			return "", errors.New("non-ASCII character before the punycode delimiter")
+		}
+		output = []rune(s[:i])
+		pos = i + 1
+	}
+
+	n, bias, i := punyInitialN, punyInitialBias, 0
+	for pos < len(s) {
+		oldi, w := i, 1
+		for k := punyBase; ; k += punyBase {
+			if pos == len(s) {
+				return "", errors.New("truncated punycode")
+			}
+			d, ok := punyDigitValue(s[pos])
+			pos++
+			if !ok {
+				return "", fmt.Errorf("invalid punycode digit %q", s[pos-1])
+			}
+			if d*w > punyMaxValue-i {
+				return "", errors.New("punycode overflow")
+			}
+			i += d * w
+			t := punyThreshold(k, bias)
+			if d < t {
+				break
+			}
+			w *= punyBase - t
+			if w > punyMaxValue {
+				return "", errors.New("punycode overflow")
+			}
+		}
+		bias = punyAdapt(i-oldi, len(output)+1, oldi == 0)
+		n += i / (len(output) + 1)
+		if n > utf8.MaxRune {
+			return "", errors.New("punycode decodes past the last code point")
+		}
+		i %= len(output) + 1
+		output = append(output[:i], append([]rune{rune(n)}, output[i:]...)...)
+		i++
+	}
+	return string(output), nil
+}
+
+func punyThreshold(k, bias int) int {
+	switch {
+	case k <= bias+punyTMin:
+		return punyTMin
+	case k >= bias+punyTMax:
+		return punyTMax
+	}
+	return k - bias
+}
+
+func punyAdapt(delta, numPoints int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > ((punyBase-punyTMin)*punyTMax)/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
+
+func punyDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+func punyDigitValue(c byte) (int, bool) {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return int(c - 'a'), true
+	case c >= 'A' && c <= 'Z':
+		return int(c - 'A'), true
+	case c >= '0' && c <= '9':
+		return int(c-'0') + 26, true
+	}
+	return 0, false
+}
@@ -0,0 +1,53 @@
+package pki
+
+import "testing"
+
+// TestPunycode tests encoding and decoding labels against RFC 3492 samples
+func TestPunycode(t *testing.T) {
+	tests := []struct {
+		unicode string
+		ascii   string
+	}{
+		{"bücher", "bcher-kva"},
+		{"münchen", "mnchen-3ya"},
+		{"他们为什么不说中文", "ihqwcrb4cv8a8dqg056pqjye"},
+		{"3年b組金八先生", "3b-ww4c5e180e575a65lsy2b"},
+	}
+	for _, tt := range tests {
+		enc, err := punycodeEncode(tt.unicode)
+		if err != nil || enc != tt.ascii {
+			t.Errorf("Expected %q to encode to %q, got %q (%v)", tt.unicode, tt.ascii, enc, err)
+		}
+		dec, err := punycodeDecode(tt.ascii)
+		if err != nil || dec != tt.unicode {
+			t.Errorf("Expected %q to decode to %q, got %q (%v)", tt.ascii, tt.unicode, dec, err)
+		}
+	}
+	for _, bad := range []string{"a!", "99999999999", "bcher-kv"} {
+		if _, err := punycodeDecode(bad); err == nil {
+			t.Errorf("Expected %q to fail to decode", bad)
+		}
+	}
+}
+
+// TestHostnameToASCII tests normalizing hostnames for comparison with SANs
+func TestHostnameToASCII(t *testing.T) {
+	tests := map[string]string{
+		"WWW.Example.COM.":      "www.example.com",
+		"Bücher.example":        "xn--bcher-kva.example",
+		"xn--BCHER-kva.example": "xn--bcher-kva.example",
+	}
+	for in, want := range tests {
+		if got, err := hostnameToASCII(in); err != nil || got != want {
+			t.Errorf("Expected %q to normalize to %q, got %q (%v)", in, want, got, err)
+		}
+	}
+	for _, bad := range []string{"", "a..b", "xn--a!.example"} {
+		if _, err := hostnameToASCII(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+	if got := hostnameToUnicode("www.xn--bcher-kva.example"); got != "www.bücher.example" {
+		t.Errorf("Expected the A-label to be decoded, got %q", got)
+	}
+}
@@ -0,0 +1,332 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PublicKeyMatches reports whether pub is the public half of key, which
+// may be an RSA, ECDSA or Ed25519 private key.
+func PublicKeyMatches(key crypto.PrivateKey, pub crypto.PublicKey) bool {
+	own, ok := publicKeyOf(key).(interface{ Equal(crypto.PublicKey) bool })
+	return ok && own.Equal(pub)
+}
+
+// KeyMatch is the outcome of comparing a private key with the public key
+// of a certificate or CSR.
+type KeyMatch struct {
+	Kind      BlockKind `json:"kind" yaml:"kind"`
+	Subject   string    `json:"subject" yaml:"subject"`
+	PublicKey string    `json:"public_key" yaml:"public_key"`
+	// SPKISHA256 is the base64 RFC 7469 pin of the object's public key.
+	SPKISHA256 string `json:"spki_sha256" yaml:"spki_sha256"`
+	Matched    bool   `json:"matched" yaml:"matched"`
+}
+
+// KeyMatchResult is the outcome of comparing one private key with a
+// certificate, a CSR or both.
+type KeyMatchResult struct {
+	PublicKey  string `json:"public_key" yaml:"public_key"`
+	SPKISHA256 string `json:"spki_sha256" yaml:"spki_sha256"`
+	// Matched is set when the key matches every object it was compared
+	// with.
+	Matched bool       `json:"matched" yaml:"matched"`
+	Results []KeyMatch `json:"results" yaml:"results"`
+}
+
+// MatchKey compares key with the public key of cert and of csr, either of
+// which may be nil.
+func MatchKey(key crypto.Signer, cert *x509.Certificate, csr *x509.CertificateRequest) (*KeyMatchResult, error) {
+	pin, err := PublicKeyPin(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	r := &KeyMatchResult{PublicKey: publicKeySummary(key.Public()), SPKISHA256: pin, Matched: true}
+	if cert != nil {
+		r.Results = append(r.Results, KeyMatch{
+			Kind:       BlockCertificate,
+			Subject:    FormatName(cert.Subject),
+			PublicKey:  publicKeySummary(cert.PublicKey),
+			SPKISHA256: pinOf(cert.RawSubjectPublicKeyInfo),
+			Matched:    PublicKeyMatches(key, cert.PublicKey),
+		})
+	}
+	if csr != nil {
+		r.Results = append(r.Results, KeyMatch{
+			Kind:       BlockCSR,
+			Subject:    FormatName(csr.Subject),
+			PublicKey:  publicKeySummary(csr.PublicKey),
+			SPKISHA256: pinOf(csr.RawSubjectPublicKeyInfo),
+			Matched:    PublicKeyMatches(key, csr.PublicKey),
+		})
+	}
+	for _, m := range r.Results {
+		r.Matched = r.Matched && m.Matched
+	}
+	return r, nil
+}
+
+// Text renders r in the certinfo text layout.
+func (r *KeyMatchResult) Text() string {
+	var buf strings.Builder
+
+	fmt.Fprintf(&buf, "Private Key:         %s\n", r.PublicKey)
+	fmt.Fprintf(&buf, "  SPKI SHA-256:      %s\n", r.SPKISHA256)
+	for _, m := range r.Results {
+		status := "MISMATCH"
+		if m.Matched {
+			status = "match"
+		}
+		fmt.Fprintf(&buf, "%-21s%s\n", m.Kind.String()+":", m.Subject)
+		fmt.Fprintf(&buf, "  Public Key:        %s\n", m.PublicKey)
+		fmt.Fprintf(&buf, "  SPKI SHA-256:      %s\n", m.SPKISHA256)
+		fmt.Fprintf(&buf, "  Key:               %s\n", status)
+	}
+	if r.Matched {
+		fmt.Fprintf(&buf, "Result:              key matches\n")
+	} else {
+		fmt.Fprintf(&buf, "Result:              KEY DOES NOT MATCH\n")
+	}
+
+	return buf.String()
+}
+
+// PublicKeyPin returns the base64 SHA-256 of pub's SubjectPublicKeyInfo,
+// the same pin SPKIPin gives for a certificate with that key.
+func PublicKeyPin(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return pinOf(der), nil
+}
+
+func pinOf(spki []byte) string {
+	sum := sha256.Sum256(spki)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// KeyObject is a private key, certificate or CSR found by PairKeys, or one
+// it could not read.
+type KeyObject struct {
+	// Source is the file the object came from and Index its 1-based
+	// position among the objects in that file.
+	Source  string    `json:"source" yaml:"source"`
+	Index   int       `json:"index" yaml:"index"`
+	Kind    BlockKind `json:"kind" yaml:"kind"`
+	Subject string    `json:"subject,omitempty" yaml:"subject,omitempty"`
+	Error   string    `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// KeyPair groups the objects that share a public key.
+type KeyPair struct {
+	SPKISHA256   string      `json:"spki_sha256" yaml:"spki_sha256"`
+	PublicKey    string      `json:"public_key" yaml:"public_key"`
+	Keys         []KeyObject `json:"keys,omitempty" yaml:"keys,omitempty"`
+	Certificates []KeyObject `json:"certificates,omitempty" yaml:"certificates,omitempty"`
+	CSRs         []KeyObject `json:"csrs,omitempty" yaml:"csrs,omitempty"`
+}
+
+// Status summarizes p: "paired" when it has a private key and a
+// certificate or CSR, otherwise what is missing.
+func (p KeyPair) Status() string {
+	switch {
+	case len(p.Keys) == 0:
+		return "no private key"
+	case len(p.Certificates) == 0 && len(p.CSRs) == 0:
+		return "no certificate or CSR"
+	default:
+		return "paired"
+	}
+}
+
+// KeyPairing is the result of PairKeys.
+type KeyPairing struct {
+	// Pairs has one entry per distinct public key: those with a private
+	// key first, then certificates and CSRs without one, each in the
+	// order found.
+	Pairs  []KeyPair   `json:"pairs" yaml:"pairs"`
+	Errors []KeyObject `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+// PairKeys walks root and groups the private keys, certificates and CSRs
+// in PEM or DER files below it by public key. Encrypted keys are
+// decrypted with password; without it, or when decryption fails, they are
+// reported in Errors along with unreadable files and objects that do not
+// parse. Files that are not PEM or DER are skipped.
+func PairKeys(root, password string) (*KeyPairing, error) {
+	result := &KeyPairing{}
+	byPin := map[string]*KeyPair{}
+	var order []string
+
+	add := func(pub crypto.PublicKey, obj KeyObject) {
+		pin, err := PublicKeyPin(pub)
+		if err != nil {
+			obj.Error = err.Error()
+			result.Errors = append(result.Errors, obj)
+			return
+		}
+		p, ok := byPin[pin]
+		if !ok {
+			p = &KeyPair{SPKISHA256: pin, PublicKey: publicKeySummary(pub)}
+			byPin[pin] = p
+			order = append(order, pin)
+		}
+		switch obj.Kind {
+		case BlockPrivateKey:
+			p.Keys = append(p.Keys, obj)
+		case BlockCertificate:
+			p.Certificates = append(p.Certificates, obj)
+		case BlockCSR:
+			p.CSRs = append(p.CSRs, obj)
+		}
+	}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			result.Errors = append(result.Errors, KeyObject{Source: path, Error: err.Error()})
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			result.Errors = append(result.Errors, KeyObject{Source: path, Error: err.Error()})
+			return nil
+		}
+		blocks, err := keyFileBlocks(data, password)
+		if err != nil {
+			result.Errors = append(result.Errors, KeyObject{Source: path, Error: err.Error()})
+			return nil
+		}
+		for i, b := range blocks {
+			obj := KeyObject{Source: path, Index: i + 1, Kind: b.Kind}
+			switch b.Kind {
+			case BlockPrivateKey:
+				key, err := ParsePrivateKey(b, password)
+				if err != nil {
+					obj.Error = err.Error()
+					result.Errors = append(result.Errors, obj)
+					continue
+				}
+				add(key.Public(), obj)
+			case BlockCertificate:
+				c, err := TryParseCert(b.Bytes)
+				if err != nil {
+					obj.Error = b.FormatError(err)
+					result.Errors = append(result.Errors, obj)
+					continue
+				}
+				obj.Subject = FormatName(c.Subject)
+				add(c.PublicKey, obj)
+			case BlockCSR:
+				csr, err := x509.ParseCertificateRequest(b.Bytes)
+				if err != nil {
+					obj.Error = b.FormatError(err)
+					result.Errors = append(result.Errors, obj)
+					continue
+				}
+				obj.Subject = FormatName(csr.Subject)
+				add(csr.PublicKey, obj)
+			case BlockPKCS7:
+				p7, err := ParsePKCS7(b.Bytes)
+				if err != nil {
+					obj.Error = b.FormatError(err)
+					result.Errors = append(result.Errors, obj)
+					continue
+				}
+				obj.Kind = BlockCertificate
+				for _, c := range p7.Certificates {
+					obj.Subject = FormatName(c.Subject)
+					add(c.PublicKey, obj)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, withKey := range []bool{true, false} {
+		for _, pin := range order {
+			if p := byPin[pin]; (len(p.Keys) > 0) == withKey {
+				result.Pairs = append(result.Pairs, *p)
+			}
+		}
+	}
+	return result, nil
+}
+
+// keyFileBlocks returns the objects in a PEM or DER file. It returns nil
+// for anything else, so that unrelated files are skipped.
+func keyFileBlocks(data []byte, password string) ([]Block, error) {
+	d, err := DecodeAny(data, password)
+	if err != nil || d.Format == "unrecognized" {
+		return nil, err
+	}
+	var out []Block
+	for _, b := range d.Blocks {
+		// classifyDER leaves Type empty for DER objects it does not
+		// recognize.
+		if b.Type != "" {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+// Text renders p one public key at a time, with the objects holding it.
+func (p *KeyPairing) Text() string {
+	var buf strings.Builder
+
+	if len(p.Pairs) == 0 {
+		buf.WriteString("No keys, certificates or CSRs found.\n")
+	}
+	for i, pair := range p.Pairs {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "Public Key:          %s\n", pair.PublicKey)
+		fmt.Fprintf(&buf, "SPKI SHA-256:        %s\n", pair.SPKISHA256)
+		for _, o := range pair.Keys {
+			fmt.Fprintf(&buf, "  Private Key:       %s\n", o.location())
+		}
+		for _, o := range pair.Certificates {
+			fmt.Fprintf(&buf, "  Certificate:       %s (%s)\n", o.location(), o.Subject)
+		}
+		for _, o := range pair.CSRs {
+			fmt.Fprintf(&buf, "  CSR:               %s (%s)\n", o.location(), o.Subject)
+		}
+		fmt.Fprintf(&buf, "Status:              %s\n", pair.Status())
+	}
+
+	if len(p.Errors) > 0 {
+		buf.WriteString("\nErrors:\n")
+		for _, o := range p.Errors {
+			fmt.Fprintf(&buf, "  %s: %s\n", o.location(), o.Error)
+		}
+	}
+
+	return buf.String()
+}
+
+// location names the file and, for files holding several objects, the
+// position of o in it.
+func (o KeyObject) location() string {
+	if o.Index > 0 {
+		return fmt.Sprintf("%s #%d", o.Source, o.Index)
+	}
+	return o.Source
+}
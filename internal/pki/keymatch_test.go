@@ -0,0 +1,175 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestKeys returns one key of each supported algorithm
+func newTestKeys(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return map[string]crypto.Signer{"RSA": rsaKey, "ECDSA": ecKey, "Ed25519": edKey}
+}
+
+// selfSigned creates a certificate and CSR for key
+func selfSigned(t *testing.T, key crypto.Signer, cn string) (*x509.Certificate, *x509.CertificateRequest) {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	der, err = x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: template.Subject}, key)
+	if err != nil {
+		t.Fatalf("Failed to create CSR: %v", err)
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		t.Fatalf("Failed to parse CSR: %v", err)
+	}
+	return cert, csr
+}
+
+// TestMatchKey tests matching keys of each algorithm against their own and
+// other certificates and CSRs
+func TestMatchKey(t *testing.T) {
+	keys := newTestKeys(t)
+	certs := map[string]*x509.Certificate{}
+	csrs := map[string]*x509.CertificateRequest{}
+	for name, key := range keys {
+		certs[name], csrs[name] = selfSigned(t, key, name)
+	}
+
+	for keyName, key := range keys {
+		for certName := range certs {
+			r, err := MatchKey(key, certs[certName], csrs[certName])
+			if err != nil {
+				t.Fatalf("MatchKey failed: %v", err)
+			}
+			want := keyName == certName
+			if r.Matched != want || len(r.Results) != 2 {
+				t.Errorf("Expected %s key against %s: matched=%t, got %t (%d results)", keyName, certName, want, r.Matched, len(r.Results))
+			}
+			for _, m := range r.Results {
+				if m.Matched != want {
+					t.Errorf("Expected %s key against %s %s: matched=%t", keyName, certName, m.Kind, want)
+				}
+			}
+		}
+	}
+
+	// A key matching the certificate but not the CSR does not match.
+	r, err := MatchKey(keys["RSA"], certs["RSA"], csrs["ECDSA"])
+	if err != nil {
+		t.Fatalf("MatchKey failed: %v", err)
+	}
+	if r.Matched || !r.Results[0].Matched || r.Results[1].Matched {
+		t.Errorf("Expected a certificate match and a CSR mismatch, got %+v", r)
+	}
+}
+
+// TestPairKeys tests grouping the keys, certificates and CSRs of a
+// directory by public key
+func TestPairKeys(t *testing.T) {
+	keys := newTestKeys(t)
+	rsaCert, rsaCSR := selfSigned(t, keys["RSA"], "rsa.example.com")
+	edCert, _ := selfSigned(t, keys["Ed25519"], "ed.example.com")
+
+	rsaKey, err := EncodeKeyPEM(keys["RSA"])
+	if err != nil {
+		t.Fatalf("Failed to encode key: %v", err)
+	}
+	ecKey, err := EncodeKeyPEM(keys["ECDSA"])
+	if err != nil {
+		t.Fatalf("Failed to encode key: %v", err)
+	}
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"rsa/server.key": rsaKey,
+		"rsa/server.crt": EncodeCertsPEM(rsaCert),
+		"rsa/server.csr": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: rsaCSR.Raw}),
+		"ec.key":         ecKey,
+		"ed.der":         edCert.Raw,
+		"enc.key":        []byte(testEncryptedKeys["PBKDF2-SHA256 AES-256-CBC"]),
+		"notes.txt":      []byte("not a key"),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := PairKeys(dir, "")
+	if err != nil {
+		t.Fatalf("PairKeys failed: %v", err)
+	}
+	if len(p.Pairs) != 3 {
+		t.Fatalf("Expected 3 public keys, got %d", len(p.Pairs))
+	}
+	status := map[string]string{}
+	for _, pair := range p.Pairs {
+		status[pair.PublicKey] = pair.Status()
+	}
+	for summary, want := range map[string]string{
+		"RSA (2048 bits)": "paired",
+		"ECDSA (P-256)":   "no certificate or CSR",
+		"Ed25519":         "no private key",
+	} {
+		if status[summary] != want {
+			t.Errorf("Expected %s to be %q, got %q", summary, want, status[summary])
+		}
+	}
+	if p.Pairs[2].PublicKey != "Ed25519" {
+		t.Errorf("Expected keys without a private key last, got %s", p.Pairs[2].PublicKey)
+	}
+	if len(p.Errors) != 1 || p.Errors[0].Source != filepath.Join(dir, "enc.key") {
+		t.Errorf("Expected the encrypted key as the only error, got %+v", p.Errors)
+	}
+
+	// With the password the encrypted key is read too.
+	p, err = PairKeys(dir, "secret")
+	if err != nil {
+		t.Fatalf("PairKeys failed: %v", err)
+	}
+	if len(p.Pairs) != 4 || len(p.Errors) != 0 {
+		t.Errorf("Expected 4 public keys and no errors, got %d and %+v", len(p.Pairs), p.Errors)
+	}
+}
@@ -0,0 +1,123 @@
+// Package lint checks X.509 certificates against CA/Browser Forum Baseline
+// Requirements and RFC 5280 style rules.
+package lint
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// Severity ranks a finding.
+type Severity int
+
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+var severityNames = map[Severity]string{
+	Info:    "info",
+	Warning: "warning",
+	Error:   "error",
+}
+
+func (s Severity) String() string {
+	if n, ok := severityNames[s]; ok {
+		return n
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// MarshalText lets Severity appear by name in JSON and YAML output.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ParseSeverity looks up a severity by name.
+func ParseSeverity(name string) (Severity, error) {
+	for s, n := range severityNames {
+		if strings.EqualFold(n, name) {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity %q", name)
+}
+
+// Finding is a single rule violation.
+type Finding struct {
+	RuleID   string   `json:"rule_id" yaml:"rule_id"`
+	Severity Severity `json:"severity" yaml:"severity"`
+	Message  string   `json:"message" yaml:"message"`
+}
+
+// Rule is a named check. Check returns one message per violation.
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+	Check       func(c *x509.Certificate) []string
+}
+
+// Lint runs every rule in Rules against c.
+func Lint(c *x509.Certificate) []Finding {
+	var out []Finding
+	for _, r := range Rules {
+		for _, msg := range r.Check(c) {
+			out = append(out, Finding{RuleID: r.ID, Severity: r.Severity, Message: msg})
+		}
+	}
+	return out
+}
+
+// MaxSeverity returns the highest severity among findings and whether
+// there were any.
+func MaxSeverity(findings []Finding) (Severity, bool) {
+	if len(findings) == 0 {
+		return Info, false
+	}
+	max := Info
+	for _, f := range findings {
+		if f.Severity > max {
+			max = f.Severity
+		}
+	}
+	return max, true
+}
+
+// isServerCert reports whether c is a TLS server leaf: not a CA, and either
+// ServerAuth in its EKUs or no EKU restriction at all.
+func isServerCert(c *x509.Certificate) bool {
+	if c.IsCA {
+		return false
+	}
+	if len(c.ExtKeyUsage) == 0 && len(c.UnknownExtKeyUsage) == 0 {
+		return len(c.DNSNames) > 0 || len(c.IPAddresses) > 0
+	}
+	for _, eku := range c.ExtKeyUsage {
+		if eku == x509.ExtKeyUsageServerAuth || eku == x509.ExtKeyUsageAny {
+			return true
+		}
+	}
+	return false
+}
+
+func isSelfIssued(c *x509.Certificate) bool {
+	return string(c.RawIssuer) == string(c.RawSubject)
+}
+
+func rsaBits(c *x509.Certificate) int {
+	if pub, ok := c.PublicKey.(*rsa.PublicKey); ok {
+		return pub.N.BitLen()
+	}
+	return 0
+}
+
+func msgIf(cond bool, format string, args ...any) []string {
+	if !cond {
+		return nil
+	}
+	return []string{fmt.Sprintf(format, args...)}
+}
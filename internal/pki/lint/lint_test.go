@@ -0,0 +1,140 @@
+package lint
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// issue creates a certificate from template, self-signed when parent is nil
+func issue(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert, key
+}
+
+func ruleIDs(findings []Finding) map[string]bool {
+	ids := map[string]bool{}
+	for _, f := range findings {
+		ids[f.RuleID] = true
+	}
+	return ids
+}
+
+// TestLintCleanChain tests that a well-formed root and leaf produce no warnings
+func TestLintCleanChain(t *testing.T) {
+	serial := new(big.Int).Lsh(big.NewInt(1), 100)
+	root, rootKey := issue(t, &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Lint Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, nil)
+	leaf, _ := issue(t, &x509.Certificate{
+		SerialNumber:          new(big.Int).Add(serial, big.NewInt(1)),
+		Subject:               pkix.Name{CommonName: "www.example.com"},
+		DNSNames:              []string{"www.example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(90 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		SubjectKeyId:          []byte{1, 2, 3, 4},
+		OCSPServer:            []string{"http://ocsp.example.com"},
+		BasicConstraintsValid: true,
+	}, root, rootKey)
+
+	for _, c := range []*x509.Certificate{root, leaf} {
+		findings := Lint(c)
+		if max, ok := MaxSeverity(findings); ok && max > Info {
+			t.Errorf("%s: expected no warnings or errors, got %+v", c.Subject.CommonName, findings)
+		}
+	}
+}
+
+// TestLintFindings tests that common defects are reported
+func TestLintFindings(t *testing.T) {
+	root, rootKey := issue(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Bad Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, nil)
+	leaf, _ := issue(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "cn.example.com"},
+		DNSNames:              []string{"other.example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(500 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}, root, rootKey)
+
+	rootIDs := ruleIDs(Lint(root))
+	for _, id := range []string{"e_ca_missing_cert_sign", "w_serial_low_entropy"} {
+		if !rootIDs[id] {
+			t.Errorf("Expected root finding %s", id)
+		}
+	}
+
+	leafIDs := ruleIDs(Lint(leaf))
+	for _, id := range []string{"e_server_validity_too_long", "w_cn_not_in_san", "e_cert_sign_without_ca", "w_leaf_missing_ski", "i_leaf_no_revocation_info"} {
+		if !leafIDs[id] {
+			t.Errorf("Expected leaf finding %s", id)
+		}
+	}
+	if max, _ := MaxSeverity(Lint(leaf)); max != Error {
+		t.Errorf("Expected max severity error, got %s", max)
+	}
+}
+
+// TestLintServerMissingSAN tests the SAN requirement for serverAuth certificates
+func TestLintServerMissingSAN(t *testing.T) {
+	cert, _ := issue(t, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "nosan.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, nil, nil)
+
+	if !ruleIDs(Lint(cert))["e_server_missing_san"] {
+		t.Error("Expected e_server_missing_san")
+	}
+}
+
+// TestParseSeverity tests severity names
+func TestParseSeverity(t *testing.T) {
+	s, err := ParseSeverity("Warning")
+	if err != nil || s != Warning {
+		t.Errorf("ParseSeverity(Warning) = %v, %v", s, err)
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("Expected error for unknown severity")
+	}
+}
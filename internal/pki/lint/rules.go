@@ -0,0 +1,208 @@
+package lint
+
+import (
+	"crypto/x509"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/tjarkko/go-demo/internal/pki"
+)
+
+// maxServerValidity is the CA/Browser Forum limit on TLS server certificate
+// lifetime (398 days).
+const maxServerValidity = 398 * 24 * time.Hour
+
+const oidExtBasicConstraints = "2.5.29.19"
+
+var weakSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
+	x509.MD2WithRSA:    true,
+	x509.MD5WithRSA:    true,
+	x509.SHA1WithRSA:   true,
+	x509.DSAWithSHA1:   true,
+	x509.ECDSAWithSHA1: true,
+}
+
+// Rules is the rule set applied by Lint. Rule IDs are prefixed with the
+// default severity (e_, w_, i_).
+var Rules = []Rule{
+	{
+		ID:          "e_rsa_key_too_small",
+		Severity:    Error,
+		Description: "RSA keys must be at least 2048 bits.",
+		Check: func(c *x509.Certificate) []string {
+			bits := rsaBits(c)
+			return msgIf(bits > 0 && bits < 2048, "RSA key is %d bits, minimum is 2048", bits)
+		},
+	},
+	{
+		ID:          "e_weak_signature_algorithm",
+		Severity:    Error,
+		Description: "Certificates other than self-signed roots must not be signed with MD2, MD5 or SHA-1.",
+		Check: func(c *x509.Certificate) []string {
+			return msgIf(weakSignatureAlgorithms[c.SignatureAlgorithm] && !isSelfIssued(c),
+				"certificate is signed with %s", c.SignatureAlgorithm)
+		},
+	},
+	{
+		ID:          "e_server_validity_too_long",
+		Severity:    Error,
+		Description: "TLS server certificates must not be valid for more than 398 days.",
+		Check: func(c *x509.Certificate) []string {
+			lifetime := c.NotAfter.Sub(c.NotBefore)
+			return msgIf(isServerCert(c) && lifetime > maxServerValidity,
+				"validity period is %d days, maximum is 398", int(lifetime.Hours()/24))
+		},
+	},
+	{
+		ID:          "e_server_missing_san",
+		Severity:    Error,
+		Description: "TLS server certificates must carry a subjectAltName extension.",
+		Check: func(c *x509.Certificate) []string {
+			serverEKU := false
+			for _, eku := range c.ExtKeyUsage {
+				serverEKU = serverEKU || eku == x509.ExtKeyUsageServerAuth
+			}
+			return msgIf(!c.IsCA && serverEKU && len(c.DNSNames) == 0 && len(c.IPAddresses) == 0,
+				"serverAuth certificate has no DNS or IP subjectAltName")
+		},
+	},
+	{
+		ID:          "w_cn_not_in_san",
+		Severity:    Warning,
+		Description: "A server certificate's common name should also appear as a SAN.",
+		Check: func(c *x509.Certificate) []string {
+			cn := c.Subject.CommonName
+			if cn == "" || !isServerCert(c) {
+				return nil
+			}
+			for _, name := range c.DNSNames {
+				if strings.EqualFold(name, cn) {
+					return nil
+				}
+			}
+			if ip := net.ParseIP(cn); ip != nil {
+				for _, san := range c.IPAddresses {
+					if san.Equal(ip) {
+						return nil
+					}
+				}
+			}
+			return []string{"common name " + cn + " is not among the subjectAltNames"}
+		},
+	},
+	{
+		ID:          "e_ca_missing_ski",
+		Severity:    Error,
+		Description: "CA certificates must include a subjectKeyIdentifier (RFC 5280 4.2.1.2).",
+		Check: func(c *x509.Certificate) []string {
+			return msgIf(c.IsCA && len(c.SubjectKeyId) == 0, "CA certificate has no subject key identifier")
+		},
+	},
+	{
+		ID:          "w_leaf_missing_ski",
+		Severity:    Warning,
+		Description: "End-entity certificates should include a subjectKeyIdentifier.",
+		Check: func(c *x509.Certificate) []string {
+			return msgIf(!c.IsCA && len(c.SubjectKeyId) == 0, "certificate has no subject key identifier")
+		},
+	},
+	{
+		ID:          "e_missing_aki",
+		Severity:    Error,
+		Description: "Certificates not self-issued must include an authorityKeyIdentifier (RFC 5280 4.2.1.1).",
+		Check: func(c *x509.Certificate) []string {
+			return msgIf(!isSelfIssued(c) && len(c.AuthorityKeyId) == 0, "certificate has no authority key identifier")
+		},
+	},
+	{
+		ID:          "e_ca_missing_cert_sign",
+		Severity:    Error,
+		Description: "CA certificates must assert keyCertSign.",
+		Check: func(c *x509.Certificate) []string {
+			return msgIf(c.IsCA && c.KeyUsage&x509.KeyUsageCertSign == 0, "CA certificate key usage lacks CertSign")
+		},
+	},
+	{
+		ID:          "e_cert_sign_without_ca",
+		Severity:    Error,
+		Description: "keyCertSign may only be asserted when basicConstraints cA is true.",
+		Check: func(c *x509.Certificate) []string {
+			return msgIf(!c.IsCA && c.KeyUsage&x509.KeyUsageCertSign != 0, "key usage asserts CertSign but the certificate is not a CA")
+		},
+	},
+	{
+		ID:          "e_ca_basic_constraints_not_critical",
+		Severity:    Error,
+		Description: "CA certificates must mark basicConstraints critical.",
+		Check: func(c *x509.Certificate) []string {
+			if !c.IsCA {
+				return nil
+			}
+			for _, e := range c.Extensions {
+				if e.Id.String() == oidExtBasicConstraints {
+					return msgIf(!e.Critical, "basicConstraints is not critical")
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "e_critical_unknown_extension",
+		Severity:    Error,
+		Description: "Critical extensions must be understood by relying parties.",
+		Check: func(c *x509.Certificate) []string {
+			var out []string
+			for _, oid := range c.UnhandledCriticalExtensions {
+				out = append(out, "unknown critical extension "+pki.FormatOID(oid.String()))
+			}
+			return out
+		},
+	},
+	{
+		ID:          "e_serial_not_positive",
+		Severity:    Error,
+		Description: "Serial numbers must be positive integers.",
+		Check: func(c *x509.Certificate) []string {
+			return msgIf(c.SerialNumber.Sign() <= 0, "serial number %s is not positive", c.SerialNumber)
+		},
+	},
+	{
+		ID:          "w_serial_low_entropy",
+		Severity:    Warning,
+		Description: "Serial numbers should contain at least 64 bits of CSPRNG output.",
+		Check: func(c *x509.Certificate) []string {
+			return msgIf(c.SerialNumber.Sign() > 0 && c.SerialNumber.BitLen() < 64,
+				"serial number is only %d bits long", c.SerialNumber.BitLen())
+		},
+	},
+	{
+		ID:          "e_not_v3_with_extensions",
+		Severity:    Error,
+		Description: "Certificates with extensions must be X.509 version 3.",
+		Check: func(c *x509.Certificate) []string {
+			return msgIf(c.Version != 3 && len(c.Extensions) > 0, "version %d certificate carries extensions", c.Version)
+		},
+	},
+	{
+		ID:          "w_expired",
+		Severity:    Warning,
+		Description: "The certificate is outside its validity period.",
+		Check: func(c *x509.Certificate) []string {
+			now := time.Now()
+			if now.After(c.NotAfter) {
+				return []string{"certificate expired on " + c.NotAfter.Format(time.RFC3339)}
+			}
+			return msgIf(now.Before(c.NotBefore), "certificate is not valid until %s", c.NotBefore.Format(time.RFC3339))
+		},
+	},
+	{
+		ID:          "i_leaf_no_revocation_info",
+		Severity:    Info,
+		Description: "End-entity certificates usually carry an OCSP or CRL location.",
+		Check: func(c *x509.Certificate) []string {
+			return msgIf(!c.IsCA && len(c.OCSPServer) == 0 && len(c.CRLDistributionPoints) == 0,
+				"certificate has neither an OCSP responder nor a CRL distribution point")
+		},
+	},
+}
@@ -0,0 +1,153 @@
+package pki
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// maxOCSPResponseSize bounds how much of a responder's reply is read.
+const maxOCSPResponseSize = 1 << 20
+
+// ocspClockSkew is how far the responder's clock may be off from ours
+// before a response counts as not yet valid or stale.
+const ocspClockSkew = 5 * time.Minute
+
+var ocspStatuses = map[int]string{
+	ocsp.Good:    "good",
+	ocsp.Revoked: "revoked",
+	ocsp.Unknown: "unknown",
+}
+
+// OCSPInfo is the structured view of a verified OCSP response.
+type OCSPInfo struct {
+	Status     string     `json:"status" yaml:"status"`
+	Serial     string     `json:"serial" yaml:"serial"`
+	ProducedAt time.Time  `json:"produced_at" yaml:"produced_at"`
+	ThisUpdate time.Time  `json:"this_update" yaml:"this_update"`
+	NextUpdate *time.Time `json:"next_update,omitempty" yaml:"next_update,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" yaml:"revoked_at,omitempty"`
+	Reason     string     `json:"reason,omitempty" yaml:"reason,omitempty"`
+	// Responder is the delegated responder certificate subject, or empty
+	// when the issuer signed the response itself.
+	Responder string `json:"responder,omitempty" yaml:"responder,omitempty"`
+}
+
+// CreateOCSPRequest builds a DER OCSP request for leaf issued by issuer.
+func CreateOCSPRequest(leaf, issuer *x509.Certificate) ([]byte, error) {
+	return ocsp.CreateRequest(leaf, issuer, nil)
+}
+
+// ParseOCSPResponse parses a DER OCSP response (from a responder or
+// stapled in a TLS handshake) for leaf and verifies its signature against
+// issuer, directly or through a delegated responder certificate. A
+// delegated responder must carry the id-kp-OCSPSigning extended key usage
+// (RFC 6960, section 4.2.2.2); without it any certificate the issuer ever
+// signed could vouch for its siblings. A response whose thisUpdate is in
+// the future or whose nextUpdate has passed is rejected as well, so that
+// an old "good" answer cannot be replayed (RFC 6960, section 3.2).
+func ParseOCSPResponse(der []byte, leaf, issuer *x509.Certificate) (*OCSPInfo, error) {
+	resp, err := ocsp.ParseResponseForCert(der, leaf, issuer)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Certificate != nil && !resp.Certificate.Equal(issuer) && !hasExtKeyUsage(resp.Certificate, x509.ExtKeyUsageOCSPSigning) {
+		return nil, fmt.Errorf("OCSP response signed by %q, which is not authorized for OCSP signing", FormatName(resp.Certificate.Subject))
+	}
+	now := time.Now()
+	if resp.ThisUpdate.After(now.Add(ocspClockSkew)) {
+		return nil, fmt.Errorf("OCSP response is not yet valid: this update is %s", resp.ThisUpdate.Format(time.RFC3339))
+	}
+	if !resp.NextUpdate.IsZero() && resp.NextUpdate.Before(now.Add(-ocspClockSkew)) {
+		return nil, fmt.Errorf("OCSP response is stale: next update was %s", resp.NextUpdate.Format(time.RFC3339))
+	}
+	return getOCSPInfo(resp), nil
+}
+
+func hasExtKeyUsage(c *x509.Certificate, usage x509.ExtKeyUsage) bool {
+	for _, u := range c.ExtKeyUsage {
+		if u == usage {
+			return true
+		}
+	}
+	return false
+}
+
+// QueryOCSP posts an OCSP request for leaf to url and returns the raw
+// response. When url is empty the first OCSP server in leaf is used.
+func QueryOCSP(url string, leaf, issuer *x509.Certificate, timeout time.Duration) ([]byte, error) {
+	if url == "" {
+		if len(leaf.OCSPServer) == 0 {
+			return nil, errors.New("certificate has no OCSP server")
+		}
+		url = leaf.OCSPServer[0]
+	}
+	req, err := CreateOCSPRequest(leaf, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Post(url, "application/ocsp-request", bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OCSP responder returned %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxOCSPResponseSize))
+}
+
+func getOCSPInfo(resp *ocsp.Response) *OCSPInfo {
+	info := &OCSPInfo{
+		Status:     ocspStatuses[resp.Status],
+		Serial:     hexifyBigInt(resp.SerialNumber),
+		ProducedAt: resp.ProducedAt,
+		ThisUpdate: resp.ThisUpdate,
+	}
+	if !resp.NextUpdate.IsZero() {
+		next := resp.NextUpdate
+		info.NextUpdate = &next
+	}
+	if resp.Status == ocsp.Revoked {
+		at := resp.RevokedAt
+		info.RevokedAt = &at
+		info.Reason = crlReasonString(resp.RevocationReason)
+	}
+	if resp.Certificate != nil {
+		info.Responder = FormatName(resp.Certificate.Subject)
+	}
+	return info
+}
+
+// Text renders info in the same layout as CertInfo.Text.
+func (info *OCSPInfo) Text() string {
+	var buf strings.Builder
+
+	fmt.Fprintf(&buf, "Cert Status:         %s\n", info.Status)
+	fmt.Fprintf(&buf, "Serial:              %s\n", info.Serial)
+	if info.RevokedAt != nil {
+		fmt.Fprintf(&buf, "Revoked At:          %s\n", info.RevokedAt.Format(time.RFC3339))
+	}
+	if info.Reason != "" {
+		fmt.Fprintf(&buf, "Revocation Reason:   %s\n", info.Reason)
+	}
+	fmt.Fprintf(&buf, "Produced At:         %s\n", info.ProducedAt.Format(time.RFC3339))
+	fmt.Fprintf(&buf, "This Update:         %s\n", info.ThisUpdate.Format(time.RFC3339))
+	if info.NextUpdate != nil {
+		fmt.Fprintf(&buf, "Next Update:         %s\n", info.NextUpdate.Format(time.RFC3339))
+	}
+	if info.Responder != "" {
+		fmt.Fprintf(&buf, "Responder:           %s\n", info.Responder)
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,200 @@
+package pki
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// newTestResponder starts an OCSP responder for root that reports every
+// serial in revoked as revoked and everything else as good
+func newTestResponder(t *testing.T, root *testIssuer, revoked ...*big.Int) *httptest.Server {
+	t.Helper()
+	return newTestResponderAt(t, root, time.Now().Add(-time.Minute), time.Now().Add(time.Hour), revoked...)
+}
+
+// newTestResponderAt starts a responder like newTestResponder whose
+// responses carry the given thisUpdate and nextUpdate
+func newTestResponderAt(t *testing.T, root *testIssuer, thisUpdate, nextUpdate time.Time, revoked ...*big.Int) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req, err := ocsp.ParseRequest(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		template := ocsp.Response{
+			Status:       ocsp.Good,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   thisUpdate,
+			NextUpdate:   nextUpdate,
+		}
+		for _, s := range revoked {
+			if s.Cmp(req.SerialNumber) == 0 {
+				template.Status = ocsp.Revoked
+				template.RevokedAt = time.Now().Add(-time.Minute)
+				template.RevocationReason = ocsp.KeyCompromise
+			}
+		}
+		resp, err := ocsp.CreateResponse(root.cert, root.cert, template, root.key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		_, _ = w.Write(resp)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// TestQueryOCSP tests querying an in-process responder
+func TestQueryOCSP(t *testing.T) {
+	root := newTestRoot(t, "OCSP Root")
+	good, _ := root.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "good"}})
+	revoked, _ := root.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "revoked"}})
+	ts := newTestResponder(t, root, revoked.SerialNumber)
+
+	testCases := []struct {
+		cert   *x509.Certificate
+		status string
+		reason string
+	}{
+		{good, "good", ""},
+		{revoked, "revoked", "keyCompromise"},
+	}
+	for _, tc := range testCases {
+		der, err := QueryOCSP(ts.URL, tc.cert, root.cert, 5*time.Second)
+		if err != nil {
+			t.Fatalf("QueryOCSP failed: %v", err)
+		}
+		info, err := ParseOCSPResponse(der, tc.cert, root.cert)
+		if err != nil {
+			t.Fatalf("ParseOCSPResponse failed: %v", err)
+		}
+		if info.Status != tc.status || info.Reason != tc.reason {
+			t.Errorf("%s: expected status %s (%s), got %s (%s)",
+				tc.cert.Subject.CommonName, tc.status, tc.reason, info.Status, info.Reason)
+		}
+		if info.Serial != hexifyBigInt(tc.cert.SerialNumber) {
+			t.Errorf("Expected serial %s, got %s", hexifyBigInt(tc.cert.SerialNumber), info.Serial)
+		}
+	}
+}
+
+// TestParseOCSPResponseWrongIssuer tests that the response signature is checked
+func TestParseOCSPResponseWrongIssuer(t *testing.T) {
+	root := newTestRoot(t, "OCSP Root")
+	leaf, _ := root.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "leaf"}})
+	ts := newTestResponder(t, root)
+
+	der, err := QueryOCSP(ts.URL, leaf, root.cert, 5*time.Second)
+	if err != nil {
+		t.Fatalf("QueryOCSP failed: %v", err)
+	}
+
+	impostor := newTestRoot(t, "OCSP Root")
+	if _, err := ParseOCSPResponse(der, leaf, impostor.cert); err == nil {
+		t.Error("Expected verification against a different issuer key to fail")
+	}
+}
+
+// TestParseOCSPResponseStale tests that expired and future-dated responses
+// are rejected
+func TestParseOCSPResponseStale(t *testing.T) {
+	root := newTestRoot(t, "OCSP Root")
+	leaf, _ := root.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "leaf"}})
+	now := time.Now()
+
+	testCases := []struct {
+		name                   string
+		thisUpdate, nextUpdate time.Time
+		err                    string
+	}{
+		{"expired", now.Add(-48 * time.Hour), now.Add(-24 * time.Hour), "stale"},
+		{"future", now.Add(time.Hour), now.Add(48 * time.Hour), "not yet valid"},
+		{"within skew", now.Add(time.Minute), now.Add(-time.Minute), ""},
+		{"no next update", now.Add(-48 * time.Hour), time.Time{}, ""},
+	}
+	for _, tc := range testCases {
+		ts := newTestResponderAt(t, root, tc.thisUpdate, tc.nextUpdate)
+		der, err := QueryOCSP(ts.URL, leaf, root.cert, 5*time.Second)
+		if err != nil {
+			t.Fatalf("QueryOCSP failed: %v", err)
+		}
+		_, err = ParseOCSPResponse(der, leaf, root.cert)
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%s: Expected the response to be accepted, got %v", tc.name, err)
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("%s: Expected a %q error, got %v", tc.name, tc.err, err)
+		}
+	}
+}
+
+// TestParseOCSPResponseDelegated tests that a delegated responder needs the
+// OCSPSigning EKU
+func TestParseOCSPResponseDelegated(t *testing.T) {
+	root := newTestRoot(t, "OCSP Root")
+	leaf, _ := root.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "leaf"}})
+	responder, responderKey := root.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "OCSP Responder"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	})
+	server, serverKey := root.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "www.example.com"},
+		DNSNames:    []string{"www.example.com"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	template := ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: leaf.SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Minute),
+		NextUpdate:   time.Now().Add(time.Hour),
+	}
+
+	template.Certificate = responder
+	der, err := ocsp.CreateResponse(root.cert, responder, template, responderKey)
+	if err != nil {
+		t.Fatalf("Failed to create response: %v", err)
+	}
+	info, err := ParseOCSPResponse(der, leaf, root.cert)
+	if err != nil {
+		t.Fatalf("Expected the delegated responder to be accepted: %v", err)
+	}
+	if info.Responder != "CN=OCSP Responder" {
+		t.Errorf("Expected responder CN=OCSP Responder, got %q", info.Responder)
+	}
+
+	template.Certificate = server
+	der, err = ocsp.CreateResponse(root.cert, server, template, serverKey)
+	if err != nil {
+		t.Fatalf("Failed to create response: %v", err)
+	}
+	if _, err := ParseOCSPResponse(der, leaf, root.cert); err == nil || !strings.Contains(err.Error(), "not authorized for OCSP signing") {
+		t.Errorf("Expected a response signed by a TLS server certificate to be rejected, got %v", err)
+	}
+}
+
+// TestQueryOCSPNoServer tests the missing-AIA error
+func TestQueryOCSPNoServer(t *testing.T) {
+	root := newTestRoot(t, "OCSP Root")
+	leaf, _ := root.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "leaf"}})
+
+	if _, err := QueryOCSP("", leaf, root.cert, time.Second); err == nil {
+		t.Error("Expected error when the certificate has no OCSP server")
+	}
+}
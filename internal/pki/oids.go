@@ -0,0 +1,261 @@
+package pki
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// oidRegistry maps dotted OIDs to the names every renderer shows for them.
+// Attribute types and algorithms use their ASN.1 (OpenSSL) names; the
+// extended key usages known to crypto/x509 use the names of
+// extKeyUsageNames.
+var oidRegistry = map[string]string{
+	// Attribute types
+	"2.5.4.3":                    "commonName",
+	"2.5.4.4":                    "surname",
+	"2.5.4.5":                    "serialNumber",
+	"2.5.4.6":                    "countryName",
+	"2.5.4.7":                    "localityName",
+	"2.5.4.8":                    "stateOrProvinceName",
+	"2.5.4.9":                    "streetAddress",
+	"2.5.4.10":                   "organizationName",
+	"2.5.4.11":                   "organizationalUnitName",
+	"2.5.4.12":                   "title",
+	"2.5.4.15":                   "businessCategory",
+	"2.5.4.17":                   "postalCode",
+	"2.5.4.42":                   "givenName",
+	"2.5.4.97":                   "organizationIdentifier",
+	"0.9.2342.19200300.100.1.1":  "userId",
+	"0.9.2342.19200300.100.1.25": "domainComponent",
+	"1.2.840.113549.1.9.1":       "emailAddress",
+	"1.2.840.113549.1.9.14":      "extensionRequest",
+	"1.3.6.1.4.1.311.60.2.1.1":   "jurisdictionLocalityName",
+	"1.3.6.1.4.1.311.60.2.1.2":   "jurisdictionStateOrProvinceName",
+	"1.3.6.1.4.1.311.60.2.1.3":   "jurisdictionCountryName",
+
+	// Algorithms
+	"1.2.840.113549.1.1.1":   "rsaEncryption",
+	"1.2.840.113549.1.1.5":   "sha1WithRSAEncryption",
+	"1.2.840.113549.1.1.10":  "rsassaPss",
+	"1.2.840.113549.1.1.11":  "sha256WithRSAEncryption",
+	"1.2.840.113549.1.1.12":  "sha384WithRSAEncryption",
+	"1.2.840.113549.1.1.13":  "sha512WithRSAEncryption",
+	"1.2.840.10045.2.1":      "id-ecPublicKey",
+	"1.2.840.10045.3.1.7":    "prime256v1",
+	"1.3.132.0.34":           "secp384r1",
+	"1.3.132.0.35":           "secp521r1",
+	"1.2.840.10045.4.3.2":    "ecdsa-with-SHA256",
+	"1.2.840.10045.4.3.3":    "ecdsa-with-SHA384",
+	"1.2.840.10045.4.3.4":    "ecdsa-with-SHA512",
+	"1.3.101.112":            "ED25519",
+	"2.16.840.1.101.3.4.2.1": "sha256",
+	"2.16.840.1.101.3.4.2.2": "sha384",
+	"2.16.840.1.101.3.4.2.3": "sha512",
+	"1.3.14.3.2.26":          "sha1",
+
+	// Password-based encryption of private keys
+	"1.2.840.113549.1.5.13":   "PBES2",
+	"1.2.840.113549.1.5.12":   "PBKDF2",
+	"1.3.6.1.4.1.11591.4.11":  "id-scrypt",
+	"1.2.840.113549.2.7":      "hmacWithSHA1",
+	"1.2.840.113549.2.8":      "hmacWithSHA224",
+	"1.2.840.113549.2.9":      "hmacWithSHA256",
+	"1.2.840.113549.2.10":     "hmacWithSHA384",
+	"1.2.840.113549.2.11":     "hmacWithSHA512",
+	"2.16.840.1.101.3.4.1.2":  "aes-128-cbc",
+	"2.16.840.1.101.3.4.1.22": "aes-192-cbc",
+	"2.16.840.1.101.3.4.1.42": "aes-256-cbc",
+	"1.2.840.113549.3.7":      "des-ede3-cbc",
+
+	// Content types
+	"1.2.840.113549.1.7.1": "pkcs7-data",
+	"1.2.840.113549.1.7.2": "pkcs7-signedData",
+	"1.2.840.113549.1.7.6": "pkcs7-encryptedData",
+
+	// Extensions
+	"2.5.29.14":               "Subject Key Identifier",
+	"2.5.29.15":               "Key Usage",
+	"2.5.29.17":               "Subject Alternative Name",
+	"2.5.29.18":               "Issuer Alternative Name",
+	"2.5.29.19":               "Basic Constraints",
+	"2.5.29.20":               "CRL Number",
+	"2.5.29.21":               "CRL Reason",
+	"2.5.29.27":               "Delta CRL Indicator",
+	"2.5.29.28":               "Issuing Distribution Point",
+	"2.5.29.30":               "Name Constraints",
+	"2.5.29.31":               "CRL Distribution Points",
+	"2.5.29.32":               "Certificate Policies",
+	"2.5.29.33":               "Policy Mappings",
+	"2.5.29.35":               "Authority Key Identifier",
+	"2.5.29.36":               "Policy Constraints",
+	"2.5.29.37":               "Extended Key Usage",
+	"2.5.29.46":               "Freshest CRL",
+	"2.5.29.54":               "Inhibit anyPolicy",
+	"1.3.6.1.5.5.7.1.1":       "Authority Information Access",
+	"1.3.6.1.5.5.7.1.3":       "Qualified Certificate Statements",
+	"1.3.6.1.5.5.7.1.11":      "Subject Information Access",
+	"1.3.6.1.5.5.7.1.24":      "TLS Feature",
+	"1.3.6.1.5.5.7.48.1.2":    "OCSP Nonce",
+	"1.3.6.1.5.5.7.48.1.5":    "OCSP No Check",
+	oidSCTList:                "CT Precertificate SCTs",
+	"1.3.6.1.4.1.11129.2.4.3": "CT Precertificate Poison",
+	"1.3.6.1.4.1.311.20.2":    "Microsoft Certificate Template Name",
+	"1.3.6.1.4.1.311.21.1":    "Microsoft CA Version",
+	"1.3.6.1.4.1.311.21.2":    "Microsoft Previous CA Certificate Hash",
+	"1.3.6.1.4.1.311.21.7":    "Microsoft Certificate Template",
+	"1.3.6.1.4.1.311.21.10":   "Microsoft Application Policies",
+	"2.16.840.1.113730.1.1":   "Netscape Cert Type",
+	"2.16.840.1.113730.1.13":  "Netscape Comment",
+
+	// Extended key usages
+	"2.5.29.37.0":             "Any",
+	"1.3.6.1.5.5.7.3.1":       "ServerAuth",
+	"1.3.6.1.5.5.7.3.2":       "ClientAuth",
+	"1.3.6.1.5.5.7.3.3":       "CodeSigning",
+	"1.3.6.1.5.5.7.3.4":       "EmailProtection",
+	"1.3.6.1.5.5.7.3.5":       "IPSECEndSystem",
+	"1.3.6.1.5.5.7.3.6":       "IPSECTunnel",
+	"1.3.6.1.5.5.7.3.7":       "IPSECUser",
+	"1.3.6.1.5.5.7.3.8":       "TimeStamping",
+	"1.3.6.1.5.5.7.3.9":       "OCSPSigning",
+	"1.3.6.1.4.1.311.10.3.3":  "MS SGC",
+	"2.16.840.1.113730.4.1":   "Netscape SGC",
+	"1.3.6.1.4.1.311.2.1.22":  "MS Commercial Code Signing",
+	"1.3.6.1.4.1.311.61.1.1":  "MS Kernel Code Signing",
+	"1.3.6.1.5.5.7.3.17":      "IPsec IKE",
+	"1.3.6.1.5.5.7.3.21":      "SSH Client",
+	"1.3.6.1.5.5.7.3.22":      "SSH Server",
+	"1.3.6.1.5.5.7.3.36":      "Document Signing",
+	"1.3.6.1.5.2.3.4":         "Kerberos Client Auth",
+	"1.3.6.1.5.2.3.5":         "Kerberos KDC",
+	"1.3.6.1.4.1.311.20.2.2":  "Smartcard Logon",
+	"1.3.6.1.4.1.311.10.3.4":  "Encrypting File System",
+	"1.3.6.1.4.1.311.10.3.12": "MS Document Signing",
+	"1.3.6.1.4.1.311.10.3.13": "MS Lifetime Signing",
+	"1.2.840.113583.1.1.5":    "Adobe Authentic Documents Trust",
+	"1.3.6.1.4.1.11129.2.4.4": "CT Precertificate Signing",
+
+	// Access methods and policy qualifiers
+	"1.3.6.1.5.5.7.48.1": "OCSP",
+	"1.3.6.1.5.5.7.48.2": "CA Issuers",
+	"1.3.6.1.5.5.7.48.3": "Time Stamping",
+	"1.3.6.1.5.5.7.48.5": "CA Repository",
+	"1.3.6.1.5.5.7.2.1":  "CPS",
+	"1.3.6.1.5.5.7.2.2":  "User Notice",
+
+	// Certificate policies
+	"2.5.29.32.0":                  "anyPolicy",
+	"2.23.140.1.1":                 "CA/B Forum Extended Validation",
+	"2.23.140.1.2.1":               "CA/B Forum Domain Validated",
+	"2.23.140.1.2.2":               "CA/B Forum Organization Validated",
+	"2.23.140.1.2.3":               "CA/B Forum Individual Validated",
+	"2.23.140.1.3":                 "CA/B Forum EV Code Signing",
+	"2.23.140.1.4.1":               "CA/B Forum Code Signing",
+	"2.23.140.1.5.1.1":             "CA/B Forum S/MIME Mailbox Validated",
+	"2.16.840.1.114412.2.1":        "DigiCert Extended Validation",
+	"2.16.840.1.114412.1.1":        "DigiCert Organization Validated",
+	"1.3.6.1.4.1.6449.1.2.1.5.1":   "Sectigo Extended Validation",
+	"2.16.840.1.114028.10.1.2":     "Entrust Extended Validation",
+	"1.3.6.1.4.1.4146.1.1":         "GlobalSign Extended Validation",
+	"2.16.756.1.89.1.2.1.1":        "SwissSign Extended Validation",
+	"1.3.6.1.4.1.34697.2.1":        "AffirmTrust Extended Validation",
+	"2.16.840.1.114413.1.7.23.3":   "GoDaddy Extended Validation",
+	"2.16.840.1.114414.1.7.23.3":   "Starfield Extended Validation",
+	"1.3.6.1.4.1.44947.1.1.1":      "ISRG Domain Validated",
+	"1.3.6.1.4.1.11129.2.5.3":      "Google Trust Services",
+	"1.2.392.200091.100.721.1":     "SECOM Extended Validation",
+	"1.3.6.1.4.1.14370.1.6":        "GeoTrust Extended Validation",
+	"2.16.528.1.1003.1.2.7":        "PKIoverheid Extended Validation",
+	"0.4.0.2042.1.4":               "ETSI EVCP",
+	"0.4.0.194112.1.4":             "ETSI QCP-w",
+	"1.3.6.1.4.1.8024.0.2.100.1.2": "QuoVadis Extended Validation",
+	"2.16.840.1.113733.1.7.23.6":   "VeriSign Extended Validation",
+	"2.16.840.1.113733.1.7.48.1":   "Thawte Extended Validation",
+}
+
+var oidRegistryMu sync.RWMutex
+
+// OIDName returns the registered name of a dotted OID, or "" when it is
+// not known.
+func OIDName(oid string) string {
+	oidRegistryMu.RLock()
+	defer oidRegistryMu.RUnlock()
+	return oidRegistry[oid]
+}
+
+// FormatOID renders a dotted OID as "Name (oid)", or as the OID alone
+// when it has no registered name.
+func FormatOID(oid string) string {
+	if name := OIDName(oid); name != "" {
+		return fmt.Sprintf("%s (%s)", name, oid)
+	}
+	return oid
+}
+
+func formatOIDs(oids []string) []string {
+	var out []string
+	for _, oid := range oids {
+		out = append(out, FormatOID(oid))
+	}
+	return out
+}
+
+// RegisterOID adds or replaces the name of a dotted OID.
+func RegisterOID(oid, name string) error {
+	if !validOID(oid) {
+		return fmt.Errorf("invalid OID %q", oid)
+	}
+	if name == "" {
+		return fmt.Errorf("OID %s: empty name", oid)
+	}
+	oidRegistryMu.Lock()
+	defer oidRegistryMu.Unlock()
+	oidRegistry[oid] = name
+	return nil
+}
+
+// LoadOIDFile registers the OIDs named in a file of "<oid> <name>" lines,
+// where the name is the rest of the line. Blank lines and lines starting
+// with "#" are ignored. Names in the file take precedence over the
+// built-in ones.
+func LoadOIDFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		oid, name := line, ""
+		if i := strings.IndexFunc(line, unicode.IsSpace); i >= 0 {
+			oid, name = line[:i], strings.TrimSpace(line[i:])
+		}
+		if err := RegisterOID(oid, name); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+	}
+	return sc.Err()
+}
+
+// validOID reports whether s is a dotted OID with at least two arcs.
+func validOID(s string) bool {
+	arcs := strings.Split(s, ".")
+	if len(arcs) < 2 || len(arcs[0]) != 1 || arcs[0] > "2" {
+		return false
+	}
+	for _, a := range arcs {
+		if _, err := strconv.ParseUint(a, 10, 64); err != nil || (len(a) > 1 && a[0] == '0') {
+			return false
+		}
+	}
+	return true
+}
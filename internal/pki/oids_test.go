@@ -0,0 +1,100 @@
+package pki
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// unregisterOID removes a test OID from the registry when the test ends
+func unregisterOID(t *testing.T, oid string) {
+	t.Cleanup(func() {
+		oidRegistryMu.Lock()
+		defer oidRegistryMu.Unlock()
+		delete(oidRegistry, oid)
+	})
+}
+
+// TestFormatOID tests rendering of known and unknown OIDs
+func TestFormatOID(t *testing.T) {
+	tests := []struct {
+		oid      string
+		expected string
+	}{
+		{"2.5.29.19", "Basic Constraints (2.5.29.19)"},
+		{"1.3.6.1.4.1.311.20.2.2", "Smartcard Logon (1.3.6.1.4.1.311.20.2.2)"},
+		{"2.16.840.1.114412.2.1", "DigiCert Extended Validation (2.16.840.1.114412.2.1)"},
+		{"2.5.4.3", "commonName (2.5.4.3)"},
+		{"1.2.3.4.5", "1.2.3.4.5"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatOID(tt.oid); got != tt.expected {
+			t.Errorf("FormatOID(%s) = %s, expected %s", tt.oid, got, tt.expected)
+		}
+	}
+}
+
+// TestRegisterOID tests OID validation and that registered names are used
+// by the certificate renderer
+func TestRegisterOID(t *testing.T) {
+	for _, oid := range []string{"", "1", "3.1", "1.2.x", "1..2", "1.02"} {
+		if err := RegisterOID(oid, "Bad"); err == nil {
+			t.Errorf("Expected error for OID %q", oid)
+		}
+	}
+	if err := RegisterOID("1.2.3", ""); err == nil {
+		t.Error("Expected error for an empty name")
+	}
+
+	unregisterOID(t, "1.3.6.1.4.1.55555.1")
+	if err := RegisterOID("1.3.6.1.4.1.55555.1", "Test Extension"); err != nil {
+		t.Fatalf("RegisterOID failed: %v", err)
+	}
+	cert, _ := newTestRoot(t, "Test Root").issue(t, &x509.Certificate{
+		Subject:            pkix.Name{CommonName: "test"},
+		UnknownExtKeyUsage: []asn1.ObjectIdentifier{{1, 3, 6, 1, 4, 1, 311, 20, 2, 2}},
+		ExtraExtensions:    []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 55555, 1}, Value: []byte{0x05, 0x00}}},
+	})
+
+	text := GetCertInfo(cert).Text()
+	if !strings.Contains(text, "Extended Key Usage:  Smartcard Logon (1.3.6.1.4.1.311.20.2.2)\n") {
+		t.Errorf("Expected named EKU in output, got:\n%s", text)
+	}
+	if !strings.Contains(text, "  Test Extension (1.3.6.1.4.1.55555.1):\n") {
+		t.Errorf("Expected registered extension name in output, got:\n%s", text)
+	}
+}
+
+// TestLoadOIDFile tests parsing of a user OID file
+func TestLoadOIDFile(t *testing.T) {
+	unregisterOID(t, "1.3.6.1.4.1.55555.2")
+	unregisterOID(t, "1.3.6.1.4.1.55555.3")
+
+	path := filepath.Join(t.TempDir(), "oids.txt")
+	data := "# site OIDs\n\n1.3.6.1.4.1.55555.2 Example Policy\n1.3.6.1.4.1.55555.3\tExample Device Id\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := LoadOIDFile(path); err != nil {
+		t.Fatalf("LoadOIDFile failed: %v", err)
+	}
+	if got := OIDName("1.3.6.1.4.1.55555.2"); got != "Example Policy" {
+		t.Errorf("Expected 'Example Policy', got '%s'", got)
+	}
+	if got := OIDName("1.3.6.1.4.1.55555.3"); got != "Example Device Id" {
+		t.Errorf("Expected 'Example Device Id', got '%s'", got)
+	}
+
+	if err := os.WriteFile(path, []byte("1.2.3 ok\nnot-an-oid name\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	unregisterOID(t, "1.2.3")
+	if err := LoadOIDFile(path); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("Expected error on line 2, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// ErrIncorrectPassword is returned when a PKCS#12 bundle cannot be
+// decrypted with the given password.
+var ErrIncorrectPassword = pkcs12.ErrIncorrectPassword
+
+// PKCS12Bundle is the decoded content of a PKCS#12 (.p12/.pfx) file: an
+// optional private key and its certificate chain, leaf first.
+type PKCS12Bundle struct {
+	Key          crypto.PrivateKey
+	Certificates []*x509.Certificate
+}
+
+// PKCS12Info is the structured view of a PKCS#12 bundle.
+type PKCS12Info struct {
+	PrivateKey *KeyInfo `json:"private_key,omitempty" yaml:"private_key,omitempty"`
+	// KeyMatches reports whether the private key belongs to the first
+	// certificate.
+	KeyMatches   bool        `json:"key_matches,omitempty" yaml:"key_matches,omitempty"`
+	Certificates []*CertInfo `json:"certificates" yaml:"certificates"`
+}
+
+// DecodePKCS12 decodes a DER PKCS#12 bundle holding a key and its chain, or
+// a trust store holding only certificates.
+func DecodePKCS12(data []byte, password string) (*PKCS12Bundle, error) {
+	key, cert, caCerts, err := pkcs12.DecodeChain(data, password)
+	if err == nil {
+		return &PKCS12Bundle{Key: key, Certificates: append([]*x509.Certificate{cert}, caCerts...)}, nil
+	}
+	if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+		return nil, err
+	}
+	if certs, tsErr := pkcs12.DecodeTrustStore(data, password); tsErr == nil {
+		return &PKCS12Bundle{Certificates: certs}, nil
+	}
+	return nil, err
+}
+
+// EncodePKCS12 builds a PKCS#12 bundle from key and its certificate chain,
+// leaf first, encrypted with password using AES-256 and PBKDF2.
+func EncodePKCS12(key crypto.PrivateKey, certs []*x509.Certificate, password string) ([]byte, error) {
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates to encode")
+	}
+	if !keyMatchesCert(key, certs[0]) {
+		return nil, errors.New("private key does not match the first certificate")
+	}
+	return pkcs12.Modern.Encode(key, certs[0], certs[1:], password)
+}
+
+// GetPKCS12Info extracts the structured details of b.
+func GetPKCS12Info(b *PKCS12Bundle) *PKCS12Info {
+	info := &PKCS12Info{Certificates: []*CertInfo{}}
+	if b.Key != nil {
+		if pub := publicKeyOf(b.Key); pub != nil {
+			ki := publicKeyInfo(pub)
+			info.PrivateKey = &ki
+		}
+		info.KeyMatches = len(b.Certificates) > 0 && keyMatchesCert(b.Key, b.Certificates[0])
+	}
+	for _, c := range b.Certificates {
+		info.Certificates = append(info.Certificates, GetCertInfo(c))
+	}
+	return info
+}
+
+// Text renders info with each certificate in the CertInfo.Text layout.
+func (info *PKCS12Info) Text() string {
+	var buf strings.Builder
+
+	switch {
+	case info.PrivateKey == nil:
+		fmt.Fprintf(&buf, "Private Key:         none\n")
+	case info.KeyMatches:
+		fmt.Fprintf(&buf, "Private Key:         %s (matches certificate #1)\n", info.PrivateKey.Summary)
+	default:
+		fmt.Fprintf(&buf, "Private Key:         %s (DOES NOT match certificate #1)\n", info.PrivateKey.Summary)
+	}
+	fmt.Fprintf(&buf, "Certificates:        %d\n", len(info.Certificates))
+	for i, c := range info.Certificates {
+		fmt.Fprintf(&buf, "\n===== Certificate #%d =====\n", i+1)
+		buf.WriteString(c.Text())
+	}
+
+	return buf.String()
+}
+
+func keyMatchesCert(key crypto.PrivateKey, cert *x509.Certificate) bool {
+	return PublicKeyMatches(key, cert.PublicKey)
+}
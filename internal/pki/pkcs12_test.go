@@ -0,0 +1,75 @@
+package pki
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"strings"
+	"testing"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// TestPKCS12RoundTrip tests encoding a key and chain and decoding it again
+func TestPKCS12RoundTrip(t *testing.T) {
+	root := newTestRoot(t, "P12 Root")
+	leaf, key := root.issue(t, &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "p12.example.com"},
+		DNSNames: []string{"p12.example.com"},
+	})
+
+	der, err := EncodePKCS12(key, []*x509.Certificate{leaf, root.cert}, "secret")
+	if err != nil {
+		t.Fatalf("EncodePKCS12 failed: %v", err)
+	}
+
+	b, err := DecodePKCS12(der, "secret")
+	if err != nil {
+		t.Fatalf("DecodePKCS12 failed: %v", err)
+	}
+	if len(b.Certificates) != 2 || !b.Certificates[0].Equal(leaf) || !b.Certificates[1].Equal(root.cert) {
+		t.Fatalf("Expected leaf and root, got %d certificates", len(b.Certificates))
+	}
+
+	info := GetPKCS12Info(b)
+	if info.PrivateKey == nil || !info.KeyMatches {
+		t.Errorf("Expected matching private key, got %+v", info.PrivateKey)
+	}
+	if info.Certificates[0].Subject != "CN=p12.example.com" {
+		t.Errorf("Expected leaf subject, got '%s'", info.Certificates[0].Subject)
+	}
+
+	if _, err := DecodePKCS12(der, "wrong"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("Expected ErrIncorrectPassword, got %v", err)
+	}
+}
+
+// TestEncodePKCS12KeyMismatch tests that a key must belong to the leaf
+func TestEncodePKCS12KeyMismatch(t *testing.T) {
+	root := newTestRoot(t, "P12 Root")
+	leaf := root.leaf(t, "p12.example.com")
+
+	if _, err := EncodePKCS12(root.key, []*x509.Certificate{leaf}, "secret"); err == nil {
+		t.Error("Expected error for mismatched key")
+	}
+}
+
+// TestDecodePKCS12TrustStore tests bundles that hold only certificates
+func TestDecodePKCS12TrustStore(t *testing.T) {
+	root := newTestRoot(t, "P12 Root")
+	der, err := pkcs12.Modern.EncodeTrustStore([]*x509.Certificate{root.cert}, "secret")
+	if err != nil {
+		t.Fatalf("EncodeTrustStore failed: %v", err)
+	}
+
+	b, err := DecodePKCS12(der, "secret")
+	if err != nil {
+		t.Fatalf("DecodePKCS12 failed: %v", err)
+	}
+	if b.Key != nil || len(b.Certificates) != 1 {
+		t.Errorf("Expected one certificate and no key, got %d and %v", len(b.Certificates), b.Key)
+	}
+	if text := GetPKCS12Info(b).Text(); !strings.HasPrefix(text, "Private Key:         none") {
+		t.Errorf("Unexpected text:\n%s", text)
+	}
+}
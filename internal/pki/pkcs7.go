@@ -0,0 +1,195 @@
+package pki
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	oidPKCS7Data       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidPKCS7SignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+)
+
+// pkcs7ContentInfo is the outer ContentInfo of RFC 5652.
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+// pkcs7RawSet captures an implicitly tagged SET OF without decoding its
+// elements.
+type pkcs7RawSet struct {
+	Raw asn1.RawContent
+}
+
+// pkcs7SignedData is the RFC 5652 SignedData. Only the certificate and CRL
+// sets are decoded; signatures are not verified.
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      asn1.RawValue
+	Certificates     pkcs7RawSet     `asn1:"optional,tag:0"`
+	CRLs             pkcs7RawSet     `asn1:"optional,tag:1"`
+	SignerInfos      []asn1.RawValue `asn1:"set"`
+}
+
+// PKCS7Bundle holds the certificates and CRLs carried in a PKCS#7
+// SignedData, such as a .p7b/.p7c chain file.
+type PKCS7Bundle struct {
+	Certificates []*x509.Certificate
+	CRLs         []*x509.RevocationList
+	// Signers is the number of SignerInfos; 0 for a degenerate
+	// certificates-only bundle.
+	Signers int
+}
+
+// PKCS7Info is the structured view of a PKCS#7 bundle.
+type PKCS7Info struct {
+	Signers      int         `json:"signers" yaml:"signers"`
+	Certificates []*CertInfo `json:"certificates" yaml:"certificates"`
+	CRLs         []*CRLInfo  `json:"crls,omitempty" yaml:"crls,omitempty"`
+}
+
+// IsPKCS7 reports whether der looks like a DER PKCS#7 SignedData.
+func IsPKCS7(der []byte) bool {
+	var ci pkcs7ContentInfo
+	rest, err := asn1.Unmarshal(der, &ci)
+	return err == nil && len(rest) == 0 && ci.ContentType.Equal(oidPKCS7SignedData)
+}
+
+// ParsePKCS7 extracts the certificates and CRLs from a DER PKCS#7
+// SignedData.
+func ParsePKCS7(der []byte) (*PKCS7Bundle, error) {
+	var ci pkcs7ContentInfo
+	if rest, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, errors.New("trailing data after PKCS#7 content")
+	}
+	if !ci.ContentType.Equal(oidPKCS7SignedData) {
+		return nil, fmt.Errorf("unsupported PKCS#7 content type %s", ci.ContentType)
+	}
+
+	var sd pkcs7SignedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, err
+	}
+
+	b := &PKCS7Bundle{Signers: len(sd.SignerInfos)}
+	if len(sd.Certificates.Raw) > 0 {
+		var set asn1.RawValue
+		if _, err := asn1.Unmarshal(sd.Certificates.Raw, &set); err != nil {
+			return nil, err
+		}
+		certs, err := x509.ParseCertificates(set.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		b.Certificates = certs
+	}
+	if len(sd.CRLs.Raw) > 0 {
+		var set asn1.RawValue
+		if _, err := asn1.Unmarshal(sd.CRLs.Raw, &set); err != nil {
+			return nil, err
+		}
+		for rest := set.Bytes; len(rest) > 0; {
+			var raw asn1.RawValue
+			var err error
+			if rest, err = asn1.Unmarshal(rest, &raw); err != nil {
+				return nil, err
+			}
+			crl, err := x509.ParseRevocationList(raw.FullBytes)
+			if err != nil {
+				return nil, err
+			}
+			b.CRLs = append(b.CRLs, crl)
+		}
+	}
+	return b, nil
+}
+
+// EncodePKCS7 builds a degenerate (unsigned, certificates-only) PKCS#7
+// SignedData in DER, the format of .p7b chain files.
+func EncodePKCS7(certs []*x509.Certificate) ([]byte, error) {
+	var raw []byte
+	for _, c := range certs {
+		raw = append(raw, c.Raw...)
+	}
+	contentInfo, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+	}{oidPKCS7Data})
+	if err != nil {
+		return nil, err
+	}
+	sd, err := asn1.Marshal(struct {
+		Version          int
+		DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+		ContentInfo      asn1.RawValue
+		Certificates     asn1.RawValue
+		SignerInfos      []asn1.RawValue `asn1:"set"`
+	}{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{},
+		ContentInfo:      asn1.RawValue{FullBytes: contentInfo},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw},
+		SignerInfos:      []asn1.RawValue{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkcs7ContentInfo{
+		ContentType: oidPKCS7SignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+}
+
+// EncodePKCS7PEM is EncodePKCS7 wrapped in a "PKCS7" PEM block.
+func EncodePKCS7PEM(certs []*x509.Certificate) ([]byte, error) {
+	der, err := EncodePKCS7(certs)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: der}), nil
+}
+
+// GetPKCS7Info extracts the structured details of b.
+func GetPKCS7Info(b *PKCS7Bundle) *PKCS7Info {
+	info := &PKCS7Info{Signers: b.Signers, Certificates: []*CertInfo{}}
+	for _, c := range b.Certificates {
+		info.Certificates = append(info.Certificates, GetCertInfo(c))
+	}
+	for _, crl := range b.CRLs {
+		info.CRLs = append(info.CRLs, GetCRLInfo(crl))
+	}
+	return info
+}
+
+// Text renders info with each certificate and CRL in its usual layout.
+func (info *PKCS7Info) Text() string {
+	var buf strings.Builder
+
+	if info.Signers == 0 {
+		fmt.Fprintf(&buf, "Type:                PKCS#7 SignedData (certificates only)\n")
+	} else {
+		fmt.Fprintf(&buf, "Type:                PKCS#7 SignedData (%d signers, not verified)\n", info.Signers)
+	}
+	fmt.Fprintf(&buf, "Certificates:        %d\n", len(info.Certificates))
+	if len(info.CRLs) > 0 {
+		fmt.Fprintf(&buf, "CRLs:                %d\n", len(info.CRLs))
+	}
+	for i, c := range info.Certificates {
+		fmt.Fprintf(&buf, "\n===== Certificate #%d =====\n", i+1)
+		buf.WriteString(c.Text())
+	}
+	for i, crl := range info.CRLs {
+		fmt.Fprintf(&buf, "\n===== CRL #%d =====\n", i+1)
+		buf.WriteString(crl.Text())
+	}
+
+	return buf.String()
+}
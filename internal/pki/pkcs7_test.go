@@ -0,0 +1,99 @@
+package pki
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+)
+
+// TestPKCS7RoundTrip tests encoding a chain as .p7b and parsing it back
+func TestPKCS7RoundTrip(t *testing.T) {
+	root := newTestRoot(t, "P7 Root")
+	inter := root.intermediate(t, "P7 Intermediate")
+	leaf := inter.leaf(t, "p7.example.com")
+	chain := []*x509.Certificate{leaf, inter.cert, root.cert}
+
+	der, err := EncodePKCS7(chain)
+	if err != nil {
+		t.Fatalf("EncodePKCS7 failed: %v", err)
+	}
+	if !IsPKCS7(der) {
+		t.Error("Expected IsPKCS7 to accept the encoded bundle")
+	}
+
+	b, err := ParsePKCS7(der)
+	if err != nil {
+		t.Fatalf("ParsePKCS7 failed: %v", err)
+	}
+	if b.Signers != 0 || len(b.CRLs) != 0 {
+		t.Errorf("Expected a degenerate bundle, got %d signers and %d CRLs", b.Signers, len(b.CRLs))
+	}
+	if len(b.Certificates) != len(chain) {
+		t.Fatalf("Expected %d certificates, got %d", len(chain), len(b.Certificates))
+	}
+	for i, c := range chain {
+		if !b.Certificates[i].Equal(c) {
+			t.Errorf("Certificate #%d differs", i+1)
+		}
+	}
+}
+
+// TestParseCertificatesPKCS7 tests that DER and PEM .p7b files are read as chains
+func TestParseCertificatesPKCS7(t *testing.T) {
+	root := newTestRoot(t, "P7 Root")
+	leaf := root.leaf(t, "p7.example.com")
+
+	der, err := EncodePKCS7([]*x509.Certificate{leaf, root.cert})
+	if err != nil {
+		t.Fatalf("EncodePKCS7 failed: %v", err)
+	}
+	pemData, err := EncodePKCS7PEM([]*x509.Certificate{leaf, root.cert})
+	if err != nil {
+		t.Fatalf("EncodePKCS7PEM failed: %v", err)
+	}
+
+	for name, data := range map[string][]byte{"DER": der, "PEM": pemData} {
+		certs, err := ParseCertificates(data)
+		if err != nil {
+			t.Errorf("%s: ParseCertificates failed: %v", name, err)
+			continue
+		}
+		if len(certs) != 2 || certs[0].Subject.CommonName != "p7.example.com" {
+			t.Errorf("%s: expected leaf and root, got %d certificates", name, len(certs))
+		}
+	}
+}
+
+// TestPKCS7BlockSummary tests that print shows the certificates in a PKCS7 block
+func TestPKCS7BlockSummary(t *testing.T) {
+	root := newTestRoot(t, "P7 Root")
+	pemData, err := EncodePKCS7PEM([]*x509.Certificate{root.leaf(t, "p7.example.com"), root.cert})
+	if err != nil {
+		t.Fatalf("EncodePKCS7PEM failed: %v", err)
+	}
+
+	blocks := ReadBlocks(pemData)
+	if len(blocks) != 1 || blocks[0].Kind != BlockPKCS7 {
+		t.Fatalf("Expected one PKCS7 block, got %+v", blocks)
+	}
+	summary, err := blocks[0].Summary()
+	if err != nil {
+		t.Fatalf("Summary failed: %v", err)
+	}
+	for _, s := range []string{"Certificates:        2", "===== Certificate #2 =====", "CN=p7.example.com", "CN=P7 Root"} {
+		if !strings.Contains(summary, s) {
+			t.Errorf("Expected summary to contain '%s'", s)
+		}
+	}
+}
+
+// TestParsePKCS7Invalid tests rejection of data that is not SignedData
+func TestParsePKCS7Invalid(t *testing.T) {
+	cert := createTestCertificate(t)
+	if IsPKCS7(cert.Raw) {
+		t.Error("Expected a certificate not to be PKCS#7")
+	}
+	if _, err := ParsePKCS7(cert.Raw); err == nil {
+		t.Error("Expected error parsing a certificate as PKCS#7")
+	}
+}
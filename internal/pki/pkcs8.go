@@ -0,0 +1,259 @@
+package pki
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des" // #nosec G502 -- decrypting existing DES-EDE3 protected keys
+	"crypto/pbkdf2"
+	"crypto/sha1" // #nosec G505 -- hmacWithSHA1 is the PBKDF2 default PRF
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrKeyEncrypted is returned for an encrypted private key when no
+// password is given.
+var ErrKeyEncrypted = errors.New("private key is encrypted")
+
+// ErrIncorrectKeyPassword is returned when an encrypted private key does
+// not decrypt with the given password.
+var ErrIncorrectKeyPassword = errors.New("incorrect private key password")
+
+const (
+	oidPBES2  = "1.2.840.113549.1.5.13"
+	oidPBKDF2 = "1.2.840.113549.1.5.12"
+	oidScrypt = "1.3.6.1.4.1.11591.4.11"
+)
+
+// pbkdf2PRFs are the PBKDF2 pseudo-random functions of RFC 8018,
+// appendix B.1.
+var pbkdf2PRFs = map[string]func() hash.Hash{
+	"1.2.840.113549.2.7":  sha1.New,
+	"1.2.840.113549.2.8":  sha256.New224,
+	"1.2.840.113549.2.9":  sha256.New,
+	"1.2.840.113549.2.10": sha512.New384,
+	"1.2.840.113549.2.11": sha512.New,
+}
+
+// pbes2Cipher is a CBC encryption scheme of RFC 8018, appendix B.2.
+type pbes2Cipher struct {
+	keyLen    int
+	newCipher func(key []byte) (cipher.Block, error)
+}
+
+var pbes2Ciphers = map[string]pbes2Cipher{
+	"2.16.840.1.101.3.4.1.2":  {16, aes.NewCipher},
+	"2.16.840.1.101.3.4.1.22": {24, aes.NewCipher},
+	"2.16.840.1.101.3.4.1.42": {32, aes.NewCipher},
+	"1.2.840.113549.3.7":      {24, des.NewTripleDESCipher},
+}
+
+// Upper bounds on the work factors of a key derivation, so that a crafted
+// key cannot stall the caller or exhaust its memory. scrypt needs 128·r·N
+// bytes for its working set and runs that mix p times over 128·r bytes.
+const (
+	maxPBKDF2Iterations = 10_000_000
+	maxScryptCost       = 1 << 20
+	maxScryptMemory     = 256 << 20
+	maxScryptParallel   = 32 // bounds r·p
+)
+
+// encryptedPrivateKeyInfo is the EncryptedPrivateKeyInfo of RFC 5958.
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int                      `asn1:"optional"`
+	PRF        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+type scryptParams struct {
+	Salt            []byte
+	CostParameter   int
+	BlockSize       int
+	Parallelization int
+	KeyLength       int `asn1:"optional"`
+}
+
+// ParsePrivateKey parses a private key block in any of the encodings
+// parsePrivateKeyBlock accepts. Encrypted keys, both PKCS#8 "ENCRYPTED
+// PRIVATE KEY" blocks and legacy OpenSSL keys with a Proc-Type header,
+// are decrypted with password; without one they return ErrKeyEncrypted.
+func ParsePrivateKey(b Block, password string) (crypto.Signer, error) {
+	switch {
+	case b.Type == "ENCRYPTED PRIVATE KEY":
+		if password == "" {
+			return nil, ErrKeyEncrypted
+		}
+		der, err := DecryptPKCS8(b.Bytes, password)
+		if err != nil {
+			return nil, err
+		}
+		b = Block{Kind: BlockPrivateKey, Type: "PRIVATE KEY", Bytes: der}
+	case strings.Contains(b.Headers["Proc-Type"], "ENCRYPTED"):
+		if password == "" {
+			return nil, ErrKeyEncrypted
+		}
+		// Legacy encryption is deprecated for being unauthenticated, but
+		// keys written by "openssl genrsa -aes256" and similar still use it.
+		der, err := x509.DecryptPEMBlock(&pem.Block{Type: b.Type, Headers: b.Headers, Bytes: b.Bytes}, []byte(password)) //nolint:staticcheck // SA1019: reading existing keys
+		if err != nil {
+			if errors.Is(err, x509.IncorrectPasswordError) { //nolint:staticcheck // SA1019
+				return nil, ErrIncorrectKeyPassword
+			}
+			return nil, err
+		}
+		b = Block{Kind: BlockPrivateKey, Type: b.Type, Bytes: der}
+	}
+
+	key, _, err := parsePrivateKeyBlock(b)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok || publicKeyOf(key) == nil {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// DecryptPKCS8 decrypts a DER EncryptedPrivateKeyInfo (RFC 5958) and
+// returns the PKCS#8 PrivateKeyInfo it holds. It supports PBES2 (RFC 8018)
+// with PBKDF2 or scrypt key derivation and AES or DES-EDE3 in CBC mode,
+// which covers what OpenSSL and most other tools write.
+func DecryptPKCS8(der []byte, password string) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("invalid encrypted private key: %w", err)
+	} else if len(rest) > 0 {
+		return nil, errors.New("invalid encrypted private key: trailing data")
+	}
+	if alg := info.Algorithm.Algorithm.String(); alg != oidPBES2 {
+		return nil, fmt.Errorf("unsupported key encryption %s", FormatOID(alg))
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("invalid PBES2 parameters: %w", err)
+	}
+
+	scheme := params.EncryptionScheme.Algorithm.String()
+	enc, ok := pbes2Ciphers[scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported key encryption cipher %s", FormatOID(scheme))
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("invalid cipher parameters: %w", err)
+	}
+
+	key, err := pbes2Key(params.KeyDerivationFunc, password, enc.keyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := enc.newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, fmt.Errorf("invalid cipher IV length %d", len(iv))
+	}
+	data := info.EncryptedData
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, errors.New("invalid encrypted private key: data is not a whole number of blocks")
+	}
+
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+	plain, ok = unpadPKCS7(plain, block.BlockSize())
+	if !ok {
+		return nil, ErrIncorrectKeyPassword
+	}
+	// The padding check lets a wrong password through now and then;
+	// parsing the result catches those.
+	var probe asn1.RawValue
+	if rest, err := asn1.Unmarshal(plain, &probe); err != nil || len(rest) > 0 || probe.Tag != asn1.TagSequence {
+		return nil, ErrIncorrectKeyPassword
+	}
+	return plain, nil
+}
+
+// pbes2Key derives a keyLen-byte encryption key from password.
+func pbes2Key(kdf pkix.AlgorithmIdentifier, password string, keyLen int) ([]byte, error) {
+	switch alg := kdf.Algorithm.String(); alg {
+	case oidPBKDF2:
+		var p pbkdf2Params
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &p); err != nil {
+			return nil, fmt.Errorf("invalid PBKDF2 parameters: %w", err)
+		}
+		if p.KeyLength != 0 && p.KeyLength != keyLen {
+			return nil, fmt.Errorf("PBKDF2 key length %d does not suit the cipher", p.KeyLength)
+		}
+		if p.Iterations < 1 || p.Iterations > maxPBKDF2Iterations {
+			return nil, fmt.Errorf("unsupported PBKDF2 iteration count %d", p.Iterations)
+		}
+		prf := "1.2.840.113549.2.7" // hmacWithSHA1, the default
+		if len(p.PRF.Algorithm) > 0 {
+			prf = p.PRF.Algorithm.String()
+		}
+		h, ok := pbkdf2PRFs[prf]
+		if !ok {
+			return nil, fmt.Errorf("unsupported PBKDF2 function %s", FormatOID(prf))
+		}
+		return pbkdf2.Key(h, password, p.Salt, p.Iterations, keyLen)
+	case oidScrypt:
+		var p scryptParams
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &p); err != nil {
+			return nil, fmt.Errorf("invalid scrypt parameters: %w", err)
+		}
+		if p.KeyLength != 0 && p.KeyLength != keyLen {
+			return nil, fmt.Errorf("scrypt key length %d does not suit the cipher", p.KeyLength)
+		}
+		n, r, par := p.CostParameter, p.BlockSize, p.Parallelization
+		switch {
+		case n < 2 || n > maxScryptCost:
+			return nil, fmt.Errorf("unsupported scrypt cost %d", n)
+		case r < 1 || r > maxScryptMemory/(128*n):
+			return nil, fmt.Errorf("unsupported scrypt block size %d for cost %d", r, n)
+		case par < 1 || par > maxScryptParallel/r:
+			return nil, fmt.Errorf("unsupported scrypt parallelization %d for block size %d", par, r)
+		}
+		return scrypt.Key([]byte(password), p.Salt, n, r, par, keyLen)
+	default:
+		return nil, fmt.Errorf("unsupported key derivation function %s", FormatOID(alg))
+	}
+}
+
+// unpadPKCS7 strips the padding of RFC 8018, section 6.1.1.
+func unpadPKCS7(b []byte, blockSize int) ([]byte, bool) {
+	if len(b) == 0 {
+		return nil, false
+	}
+	n := int(b[len(b)-1])
+	if n == 0 || n > blockSize || n > len(b) {
+		return nil, false
+	}
+	if !bytes.Equal(b[len(b)-n:], bytes.Repeat([]byte{byte(n)}, n)) {
+		return nil, false
+	}
+	return b[:len(b)-n], true
+}
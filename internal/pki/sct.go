@@ -0,0 +1,323 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// oidSCTList is the embedded SCT list extension of RFC 6962.
+const oidSCTList = "1.3.6.1.4.1.11129.2.4.2"
+
+// SCT verification statuses.
+const (
+	SCTValid      = "valid"
+	SCTInvalid    = "invalid"
+	SCTUnknownLog = "unknown log"
+	SCTUnverified = "not verified"
+)
+
+// SCT is a Signed Certificate Timestamp (RFC 6962, section 3.2) as
+// embedded in a certificate.
+type SCT struct {
+	Version    uint8
+	LogID      []byte
+	Timestamp  time.Time
+	Extensions []byte
+	// HashAlgorithm and SignatureAlgorithm are the TLS
+	// SignatureAndHashAlgorithm codes (e.g. 4 = SHA-256, 3 = ECDSA).
+	HashAlgorithm      uint8
+	SignatureAlgorithm uint8
+	Signature          []byte
+}
+
+var tlsHashNames = map[uint8]string{1: "MD5", 2: "SHA1", 3: "SHA224", 4: "SHA256", 5: "SHA384", 6: "SHA512"}
+
+var tlsSignatureNames = map[uint8]string{1: "RSA", 2: "DSA", 3: "ECDSA"}
+
+// SignatureName renders the SCT's signature algorithm, e.g. "ECDSA-SHA256".
+func (s SCT) SignatureName() string {
+	hash, ok := tlsHashNames[s.HashAlgorithm]
+	if !ok {
+		hash = fmt.Sprintf("hash(%d)", s.HashAlgorithm)
+	}
+	sig, ok := tlsSignatureNames[s.SignatureAlgorithm]
+	if !ok {
+		sig = fmt.Sprintf("sig(%d)", s.SignatureAlgorithm)
+	}
+	return sig + "-" + hash
+}
+
+// ParseSCTList parses the value of the embedded SCT list extension
+// (1.3.6.1.4.1.11129.2.4.2): a DER OCTET STRING holding a TLS-encoded
+// SignedCertificateTimestampList.
+func ParseSCTList(extValue []byte) ([]SCT, error) {
+	var list []byte
+	if rest, err := asn1.Unmarshal(extValue, &list); err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, errors.New("trailing data after SCT list")
+	}
+
+	body, rest, err := tlsVector16(list)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("trailing data after SCT list")
+	}
+
+	var scts []SCT
+	for len(body) > 0 {
+		var raw []byte
+		if raw, body, err = tlsVector16(body); err != nil {
+			return nil, err
+		}
+		sct, err := parseSCT(raw)
+		if err != nil {
+			return nil, fmt.Errorf("SCT #%d: %w", len(scts)+1, err)
+		}
+		scts = append(scts, sct)
+	}
+	return scts, nil
+}
+
+func parseSCT(raw []byte) (SCT, error) {
+	var s SCT
+	// version(1) log_id(32) timestamp(8)
+	if len(raw) < 41 {
+		return s, errors.New("truncated SCT")
+	}
+	s.Version = raw[0]
+	if s.Version != 0 {
+		return s, fmt.Errorf("unsupported SCT version %d", s.Version)
+	}
+	s.LogID = raw[1:33]
+	ms := binary.BigEndian.Uint64(raw[33:41])
+	s.Timestamp = time.UnixMilli(int64(ms)).UTC()
+
+	var err error
+	rest := raw[41:]
+	if s.Extensions, rest, err = tlsVector16(rest); err != nil {
+		return s, err
+	}
+	if len(rest) < 2 {
+		return s, errors.New("truncated SCT signature")
+	}
+	s.HashAlgorithm, s.SignatureAlgorithm = rest[0], rest[1]
+	if s.Signature, rest, err = tlsVector16(rest[2:]); err != nil {
+		return s, err
+	}
+	if len(rest) > 0 {
+		return s, errors.New("trailing data after SCT")
+	}
+	return s, nil
+}
+
+// SCTInfo is the structured view of an embedded SCT and the outcome of
+// verifying its signature.
+type SCTInfo struct {
+	LogID              string    `json:"log_id" yaml:"log_id"`
+	Log                string    `json:"log,omitempty" yaml:"log,omitempty"`
+	Operator           string    `json:"operator,omitempty" yaml:"operator,omitempty"`
+	Timestamp          time.Time `json:"timestamp" yaml:"timestamp"`
+	SignatureAlgorithm string    `json:"signature_algorithm" yaml:"signature_algorithm"`
+	Status             string    `json:"status" yaml:"status"`
+	Error              string    `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// CheckSCTs parses the SCTs embedded in cert and verifies each one against
+// the key of the log in logs that issued it. Verification needs the
+// certificate's issuer; without it, or without a log list, SCTs are
+// reported as SCTUnverified. It returns nil when cert has no SCTs.
+func CheckSCTs(cert, issuer *x509.Certificate, logs *CTLogList) ([]SCTInfo, error) {
+	var scts []SCT
+	for _, e := range cert.Extensions {
+		if e.Id.String() == oidSCTList {
+			var err error
+			if scts, err = ParseSCTList(e.Value); err != nil {
+				return nil, fmt.Errorf("invalid SCT list: %w", err)
+			}
+		}
+	}
+
+	var out []SCTInfo
+	for _, s := range scts {
+		info := SCTInfo{
+			LogID:              base64.StdEncoding.EncodeToString(s.LogID),
+			Timestamp:          s.Timestamp,
+			SignatureAlgorithm: s.SignatureName(),
+			Status:             SCTUnverified,
+		}
+		log := logs.Find(s.LogID)
+		switch {
+		case logs == nil:
+			info.Error = "no CT log list"
+		case log == nil:
+			info.Status = SCTUnknownLog
+		case issuer == nil:
+			info.Error = "issuer certificate not available"
+		default:
+			info.Status = SCTValid
+			if err := VerifySCT(s, cert, issuer, log.Key); err != nil {
+				info.Status = SCTInvalid
+				info.Error = err.Error()
+			}
+		}
+		if log != nil {
+			info.Log = log.Description
+			info.Operator = log.Operator
+		}
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+// VerifySCT checks the signature of s, embedded in cert, with the log's
+// public key. The signed data is rebuilt from the precertificate: cert's
+// TBSCertificate without the SCT list extension, and the hash of issuer's
+// public key.
+func VerifySCT(s SCT, cert, issuer *x509.Certificate, key crypto.PublicKey) error {
+	if s.HashAlgorithm != 4 {
+		return fmt.Errorf("unsupported SCT signature algorithm %s", s.SignatureName())
+	}
+	data, err := precertSignedData(s, cert, issuer)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(data)
+
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if s.SignatureAlgorithm != 3 {
+			return fmt.Errorf("%s signature from an ECDSA log key", s.SignatureName())
+		}
+		if !ecdsa.VerifyASN1(k, digest[:], s.Signature) {
+			return errors.New("signature verification failed")
+		}
+		return nil
+	case *rsa.PublicKey:
+		if s.SignatureAlgorithm != 1 {
+			return fmt.Errorf("%s signature from an RSA log key", s.SignatureName())
+		}
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], s.Signature); err != nil {
+			return errors.New("signature verification failed")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported log key type %T", key)
+	}
+}
+
+// precertSignedData builds the digitally-signed struct of RFC 6962,
+// section 3.2, for a precert_entry.
+func precertSignedData(s SCT, cert, issuer *x509.Certificate) ([]byte, error) {
+	tbs, err := precertTBS(cert.RawTBSCertificate)
+	if err != nil {
+		return nil, err
+	}
+	if len(tbs) >= 1<<24 {
+		return nil, errors.New("TBSCertificate too large")
+	}
+	keyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+
+	b := []byte{s.Version, 0} // signature_type certificate_timestamp
+	b = binary.BigEndian.AppendUint64(b, uint64(s.Timestamp.UnixMilli()))
+	b = append(b, 0, 1) // entry_type precert_entry
+	b = append(b, keyHash[:]...)
+	b = append(b, byte(len(tbs)>>16), byte(len(tbs)>>8), byte(len(tbs)))
+	b = append(b, tbs...)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s.Extensions)))
+	return append(b, s.Extensions...), nil
+}
+
+// precertTBS re-encodes a TBSCertificate without its SCT list extension,
+// recovering the TBSCertificate the log signed.
+func precertTBS(rawTBS []byte) ([]byte, error) {
+	elems, err := sequenceElements(rawTBS)
+	if err != nil {
+		return nil, err
+	}
+	var body []byte
+	for _, e := range elems {
+		if e.Class != asn1.ClassContextSpecific || e.Tag != 3 {
+			body = append(body, e.FullBytes...)
+			continue
+		}
+		exts, err := sequenceElements(e.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		var kept []byte
+		for _, x := range exts {
+			var id asn1.ObjectIdentifier
+			if _, err := asn1.Unmarshal(x.Bytes, &id); err != nil {
+				return nil, err
+			}
+			if id.String() != oidSCTList {
+				kept = append(kept, x.FullBytes...)
+			}
+		}
+		seq, err := asn1.Marshal(asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: kept})
+		if err != nil {
+			return nil, err
+		}
+		explicit, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 3, IsCompound: true, Bytes: seq})
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, explicit...)
+	}
+	return asn1.Marshal(asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: body})
+}
+
+// SCTText renders the SCTs of a certificate, one indented entry per SCT.
+func SCTText(scts []SCTInfo) string {
+	var buf strings.Builder
+
+	if len(scts) == 0 {
+		return "SCTs:                none\n"
+	}
+	fmt.Fprintf(&buf, "SCTs:                %d\n", len(scts))
+	for i, s := range scts {
+		name := s.Log
+		if name == "" {
+			name = "unknown log"
+		}
+		if s.Operator != "" {
+			name += " (" + s.Operator + ")"
+		}
+		fmt.Fprintf(&buf, "  #%d %s\n", i+1, name)
+		fmt.Fprintf(&buf, "      Log ID:        %s\n", s.LogID)
+		fmt.Fprintf(&buf, "      Timestamp:     %s\n", s.Timestamp.Format(time.RFC3339))
+		fmt.Fprintf(&buf, "      Signature:     %s\n", s.SignatureAlgorithm)
+		if s.Error != "" {
+			fmt.Fprintf(&buf, "      Status:        %s (%s)\n", s.Status, s.Error)
+		} else {
+			fmt.Fprintf(&buf, "      Status:        %s\n", s.Status)
+		}
+	}
+
+	return buf.String()
+}
+
+// tlsVector16 splits a TLS opaque<0..2^16-1> off the front of b.
+func tlsVector16(b []byte) (vec, rest []byte, err error) {
+	if len(b) < 2 {
+		return nil, nil, errors.New("truncated length")
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return nil, nil, errors.New("truncated vector")
+	}
+	return b[2 : 2+n], b[2+n:], nil
+}
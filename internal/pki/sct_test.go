@@ -0,0 +1,159 @@
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// TestParseSCTList tests decoding of a TLS-encoded SCT list
+func TestParseSCTList(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	scts, err := ParseSCTList(testSCTList(t, make([]byte, 32), ts, []byte{0xAB, 0xCD}))
+	if err != nil {
+		t.Fatalf("ParseSCTList failed: %v", err)
+	}
+	if len(scts) != 1 {
+		t.Fatalf("Expected 1 SCT, got %d", len(scts))
+	}
+	s := scts[0]
+	if !s.Timestamp.Equal(ts) {
+		t.Errorf("Expected timestamp %v, got %v", ts, s.Timestamp)
+	}
+	if len(s.LogID) != 32 || string(s.Signature) != "\xab\xcd" {
+		t.Errorf("Unexpected SCT fields: %+v", s)
+	}
+	if s.SignatureName() != "ECDSA-SHA256" {
+		t.Errorf("Expected ECDSA-SHA256, got %s", s.SignatureName())
+	}
+
+	if _, err := ParseSCTList([]byte{0x04, 0x02, 0x00, 0x05}); err == nil {
+		t.Error("Expected error for truncated list")
+	}
+}
+
+// testLog is a CT log key and the log list that describes it
+type testLog struct {
+	key  *ecdsa.PrivateKey
+	id   []byte
+	list *CTLogList
+}
+
+func newTestLog(t *testing.T) *testLog {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	spki, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	id := sha256.Sum256(spki)
+	data := fmt.Sprintf(`{"operators": [{"name": "Test Operator", "logs": [{"description": "Test Log", "key": %q, "url": "https://ct.example.com/", "state": {"usable": {}}}]}]}`,
+		base64.StdEncoding.EncodeToString(spki))
+	list, err := ParseCTLogList([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseCTLogList failed: %v", err)
+	}
+	return &testLog{key: key, id: id[:], list: list}
+}
+
+// issueWithSCT issues a certificate the way a CA does for CT: the
+// precertificate is signed by the log, then the final certificate is
+// issued with the SCT embedded
+func (l *testLog) issueWithSCT(t *testing.T, root *testIssuer) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "ct.example.com"},
+		DNSNames:     []string{"ct.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour).Truncate(time.Second),
+		NotAfter:     time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, root.cert, &key.PublicKey, root.key)
+	if err != nil {
+		t.Fatalf("Failed to create precertificate: %v", err)
+	}
+	precert, _ := x509.ParseCertificate(der)
+
+	ts := time.Now().Truncate(time.Millisecond)
+	keyHash := sha256.Sum256(root.cert.RawSubjectPublicKeyInfo)
+	tbs := precert.RawTBSCertificate
+	signed := []byte{0, 0}
+	signed = binary.BigEndian.AppendUint64(signed, uint64(ts.UnixMilli()))
+	signed = append(signed, 0, 1)
+	signed = append(signed, keyHash[:]...)
+	signed = append(signed, byte(len(tbs)>>16), byte(len(tbs)>>8), byte(len(tbs)))
+	signed = append(signed, tbs...)
+	signed = append(signed, 0, 0)
+	digest := sha256.Sum256(signed)
+	sig, err := ecdsa.SignASN1(rand.Reader, l.key, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign SCT: %v", err)
+	}
+
+	template.ExtraExtensions = []pkix.Extension{{
+		Id:    asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2},
+		Value: testSCTList(t, l.id, ts, sig),
+	}}
+	der, err = x509.CreateCertificate(rand.Reader, template, root.cert, &key.PublicKey, root.key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert
+}
+
+// TestCheckSCTs tests SCT signature verification against a log list
+func TestCheckSCTs(t *testing.T) {
+	root := newTestRoot(t, "CT Root")
+	log := newTestLog(t)
+	cert := log.issueWithSCT(t, root)
+
+	scts, err := CheckSCTs(cert, root.cert, log.list)
+	if err != nil {
+		t.Fatalf("CheckSCTs failed: %v", err)
+	}
+	if len(scts) != 1 {
+		t.Fatalf("Expected 1 SCT, got %d", len(scts))
+	}
+	if scts[0].Status != SCTValid || scts[0].Log != "Test Log" || scts[0].Operator != "Test Operator" {
+		t.Errorf("Expected valid SCT from Test Log, got %+v", scts[0])
+	}
+
+	other := newTestRoot(t, "Other Root")
+	scts, _ = CheckSCTs(cert, other.cert, log.list)
+	if scts[0].Status != SCTInvalid {
+		t.Errorf("Expected invalid SCT with the wrong issuer, got %+v", scts[0])
+	}
+
+	scts, _ = CheckSCTs(cert, nil, log.list)
+	if scts[0].Status != SCTUnverified {
+		t.Errorf("Expected unverified SCT without an issuer, got %+v", scts[0])
+	}
+
+	scts, _ = CheckSCTs(cert, root.cert, newTestLog(t).list)
+	if scts[0].Status != SCTUnknownLog {
+		t.Errorf("Expected unknown log, got %+v", scts[0])
+	}
+
+	if scts, err := CheckSCTs(root.cert, nil, log.list); err != nil || scts != nil {
+		t.Errorf("Expected no SCTs for a certificate without them, got %v, %v", scts, err)
+	}
+}
@@ -0,0 +1,217 @@
+package pki
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// CertNode is one certificate of a CertTree.
+type CertNode struct {
+	// Index is the certificate's 1-based position in the input; the other
+	// index fields refer to nodes by it.
+	Index      int    `json:"index" yaml:"index"`
+	Subject    string `json:"subject" yaml:"subject"`
+	Issuer     string `json:"issuer" yaml:"issuer"`
+	Serial     string `json:"serial" yaml:"serial"`
+	SelfSigned bool   `json:"self_signed" yaml:"self_signed"`
+	// IssuedBy lists every certificate whose key verifies this one's
+	// signature and whose subject and key ID match its issuer. The first
+	// is the node's parent in the tree.
+	IssuedBy []int `json:"issued_by,omitempty" yaml:"issued_by,omitempty"`
+	// SameKeyAs lists certificates with this one's subject and public key
+	// but a different issuer: the other versions of a cross-signed CA.
+	SameKeyAs []int `json:"same_key_as,omitempty" yaml:"same_key_as,omitempty"`
+	// Orphan is set when the certificate is not self-signed and its
+	// issuer is not among the input.
+	Orphan bool `json:"orphan" yaml:"orphan"`
+	// DuplicateOf is the first earlier copy of the same certificate, if
+	// any.
+	DuplicateOf int `json:"duplicate_of,omitempty" yaml:"duplicate_of,omitempty"`
+}
+
+// CrossSigned reports whether n is one of several certificates for the
+// same CA name and key.
+func (n CertNode) CrossSigned() bool {
+	return len(n.SameKeyAs) > 0
+}
+
+// CertTree records which certificate of a set issued which.
+type CertTree struct {
+	Nodes []CertNode `json:"nodes" yaml:"nodes"`
+}
+
+// BuildCertTree works out the issuer of every certificate in certs among
+// the others, matched by name, AKI/SKI and signature.
+func BuildCertTree(certs []*x509.Certificate) *CertTree {
+	t := &CertTree{}
+	for i, c := range certs {
+		n := CertNode{
+			Index:      i + 1,
+			Subject:    FormatName(c.Subject),
+			Issuer:     FormatName(c.Issuer),
+			Serial:     hexifyBigInt(c.SerialNumber),
+			SelfSigned: isSelfSigned(c),
+		}
+		for j, other := range certs {
+			if i == j {
+				continue
+			}
+			if c.Equal(other) {
+				if j < i && n.DuplicateOf == 0 {
+					n.DuplicateOf = j + 1
+				}
+				continue
+			}
+			if signedBy(c, other) {
+				n.IssuedBy = append(n.IssuedBy, j+1)
+			}
+			if bytes.Equal(c.RawSubject, other.RawSubject) &&
+				bytes.Equal(c.RawSubjectPublicKeyInfo, other.RawSubjectPublicKeyInfo) &&
+				!bytes.Equal(c.RawIssuer, other.RawIssuer) {
+				n.SameKeyAs = append(n.SameKeyAs, j+1)
+			}
+		}
+		n.Orphan = !n.SelfSigned && len(n.IssuedBy) == 0
+		t.Nodes = append(t.Nodes, n)
+	}
+	return t
+}
+
+// node returns the node with the given index.
+func (t *CertTree) node(index int) CertNode {
+	return t.Nodes[index-1]
+}
+
+// children maps each node index to the nodes whose first issuer it is.
+func (t *CertTree) children() map[int][]int {
+	out := map[int][]int{}
+	for _, n := range t.Nodes {
+		if len(n.IssuedBy) > 0 {
+			out[n.IssuedBy[0]] = append(out[n.IssuedBy[0]], n.Index)
+		}
+	}
+	return out
+}
+
+// Text draws t as a tree, each certificate under the one that issued it.
+// Self-signed roots and orphans are at the top; issuers beyond the first
+// and other versions of cross-signed CAs are noted after the subject.
+func (t *CertTree) Text() string {
+	var buf strings.Builder
+
+	children := t.children()
+	visited := map[int]bool{}
+	var draw func(index int, prefix, branch, indent string)
+	draw = func(index int, prefix, branch, indent string) {
+		visited[index] = true
+		fmt.Fprintf(&buf, "%s%s%s\n", prefix, branch, t.label(index))
+		kids := children[index]
+		for i, k := range kids {
+			if visited[k] {
+				continue
+			}
+			if i == len(kids)-1 {
+				draw(k, prefix+indent, "└── ", "    ")
+			} else {
+				draw(k, prefix+indent, "├── ", "│   ")
+			}
+		}
+	}
+	for _, n := range t.Nodes {
+		if len(n.IssuedBy) == 0 {
+			draw(n.Index, "", "", "")
+		}
+	}
+	// Certificates that issued each other in a loop have no top; start
+	// from the first one not yet drawn.
+	for _, n := range t.Nodes {
+		if !visited[n.Index] {
+			draw(n.Index, "", "", "")
+		}
+	}
+
+	return buf.String()
+}
+
+// label renders a node for Text: its index, subject and markers.
+func (t *CertTree) label(index int) string {
+	n := t.node(index)
+	var marks []string
+	switch {
+	case n.SelfSigned:
+		marks = append(marks, "self-signed")
+	case n.Orphan:
+		marks = append(marks, "orphan: issuer "+n.Issuer+" not found")
+	}
+	if n.DuplicateOf != 0 {
+		marks = append(marks, fmt.Sprintf("duplicate of #%d", n.DuplicateOf))
+	}
+	if n.CrossSigned() {
+		marks = append(marks, "cross-signed, same key as "+indexList(n.SameKeyAs))
+	}
+	if len(n.IssuedBy) > 1 {
+		marks = append(marks, "also issued by "+indexList(n.IssuedBy[1:]))
+	}
+	s := fmt.Sprintf("#%d %s", n.Index, n.Subject)
+	if len(marks) > 0 {
+		s += " [" + strings.Join(marks, "; ") + "]"
+	}
+	return s
+}
+
+func indexList(indexes []int) string {
+	parts := make([]string, len(indexes))
+	for i, x := range indexes {
+		parts[i] = fmt.Sprintf("#%d", x)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DOT renders t as a Graphviz digraph with an edge from each issuer to the
+// certificates it issued. Self-signed roots are drawn with a double
+// border, the missing issuers of orphans as dashed nodes, and the versions
+// of a cross-signed CA are joined by a dotted line.
+func (t *CertTree) DOT() string {
+	var buf strings.Builder
+
+	buf.WriteString("digraph certificates {\n")
+	buf.WriteString("  node [shape=box, fontname=\"Helvetica\"];\n")
+	for _, n := range t.Nodes {
+		attrs := fmt.Sprintf("label=%s", dotQuote(fmt.Sprintf("#%d\n%s", n.Index, n.Subject)))
+		if n.SelfSigned {
+			attrs += ", peripheries=2"
+		}
+		fmt.Fprintf(&buf, "  c%d [%s];\n", n.Index, attrs)
+	}
+	missing := map[string]string{}
+	for _, n := range t.Nodes {
+		for _, issuer := range n.IssuedBy {
+			fmt.Fprintf(&buf, "  c%d -> c%d;\n", issuer, n.Index)
+		}
+		if n.Orphan {
+			id, ok := missing[n.Issuer]
+			if !ok {
+				id = fmt.Sprintf("missing%d", len(missing)+1)
+				missing[n.Issuer] = id
+				fmt.Fprintf(&buf, "  %s [label=%s, style=dashed];\n", id, dotQuote(n.Issuer+"\n(not in input)"))
+			}
+			fmt.Fprintf(&buf, "  %s -> c%d [style=dashed];\n", id, n.Index)
+		}
+		for _, other := range n.SameKeyAs {
+			if other > n.Index {
+				fmt.Fprintf(&buf, "  c%d -> c%d [style=dotted, dir=none, label=\"same key\"];\n", n.Index, other)
+			}
+		}
+	}
+	buf.WriteString("}\n")
+
+	return buf.String()
+}
+
+// dotQuote quotes s as a DOT string, keeping newlines as line breaks.
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,101 @@
+package pki
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"strings"
+	"testing"
+)
+
+// TestBuildCertTree tests nesting a shuffled bundle under its root and
+// marking a duplicate
+func TestBuildCertTree(t *testing.T) {
+	root := newTestRoot(t, "Tree Root")
+	inter := root.intermediate(t, "Tree Intermediate")
+	leaf := inter.leaf(t, "tree.example.com")
+
+	tree := BuildCertTree([]*x509.Certificate{leaf, root.cert, inter.cert, leaf})
+	if n := tree.Nodes[0]; len(n.IssuedBy) != 1 || n.IssuedBy[0] != 3 || n.Orphan {
+		t.Errorf("Expected the leaf to be issued by #3, got %+v", n)
+	}
+	if n := tree.Nodes[1]; !n.SelfSigned || len(n.IssuedBy) != 0 {
+		t.Errorf("Expected the root to be self-signed, got %+v", n)
+	}
+	if n := tree.Nodes[3]; n.DuplicateOf != 1 {
+		t.Errorf("Expected #4 to be a duplicate of #1, got %+v", n)
+	}
+
+	want := "#2 CN=Tree Root [self-signed]\n" +
+		"└── #3 CN=Tree Intermediate\n" +
+		"    ├── #1 CN=tree.example.com\n" +
+		"    └── #4 CN=tree.example.com [duplicate of #1]\n"
+	if got := tree.Text(); got != want {
+		t.Errorf("Expected tree:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+// TestBuildCertTreeOrphan tests marking a certificate whose issuer is missing
+func TestBuildCertTreeOrphan(t *testing.T) {
+	root := newTestRoot(t, "Tree Root")
+	inter := root.intermediate(t, "Tree Intermediate")
+	leaf := inter.leaf(t, "tree.example.com")
+
+	tree := BuildCertTree([]*x509.Certificate{leaf, root.cert})
+	if !tree.Nodes[0].Orphan {
+		t.Errorf("Expected the leaf to be an orphan, got %+v", tree.Nodes[0])
+	}
+	if text := tree.Text(); !strings.Contains(text, "#1 CN=tree.example.com [orphan: issuer CN=Tree Intermediate not found]") {
+		t.Errorf("Expected the orphan to be marked:\n%s", text)
+	}
+	dot := tree.DOT()
+	for _, s := range []string{"c2 [label=\"#2\\nCN=Tree Root\", peripheries=2];", "missing1 [label=\"CN=Tree Intermediate\\n(not in input)\", style=dashed];", "missing1 -> c1 [style=dashed];"} {
+		if !strings.Contains(dot, s) {
+			t.Errorf("Expected DOT output to contain '%s':\n%s", s, dot)
+		}
+	}
+}
+
+// TestBuildCertTreeCrossSigned tests marking both versions of a cross-signed
+// root and a leaf that either can verify
+func TestBuildCertTreeCrossSigned(t *testing.T) {
+	oldRoot := newTestRoot(t, "Old Root")
+	newRoot := newTestRoot(t, "New Root")
+	cross, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:          newRoot.cert.SerialNumber,
+		Subject:               newRoot.cert.Subject,
+		NotBefore:             newRoot.cert.NotBefore,
+		NotAfter:              newRoot.cert.NotAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SubjectKeyId:          newRoot.cert.SubjectKeyId,
+	}, oldRoot.cert, newRoot.key.Public(), oldRoot.key)
+	if err != nil {
+		t.Fatalf("Failed to cross-sign: %v", err)
+	}
+	crossCert, err := x509.ParseCertificate(cross)
+	if err != nil {
+		t.Fatalf("Failed to parse cross-signed certificate: %v", err)
+	}
+	leaf := newRoot.leaf(t, "cross.example.com")
+
+	tree := BuildCertTree([]*x509.Certificate{oldRoot.cert, crossCert, newRoot.cert, leaf})
+	if n := tree.Nodes[1]; !n.CrossSigned() || n.SameKeyAs[0] != 3 {
+		t.Errorf("Expected #2 to share its key with #3, got %+v", n)
+	}
+	if n := tree.Nodes[3]; len(n.IssuedBy) != 2 {
+		t.Errorf("Expected the leaf to be verified by both versions, got %+v", n)
+	}
+	text := tree.Text()
+	for _, s := range []string{"#2 CN=New Root [cross-signed, same key as #3]", "#4 CN=cross.example.com [also issued by #3]"} {
+		if !strings.Contains(text, s) {
+			t.Errorf("Expected tree to contain '%s':\n%s", s, text)
+		}
+	}
+	dot := tree.DOT()
+	for _, s := range []string{"c1 -> c2;", "c2 -> c4;", "c3 -> c4;", "c2 -> c3 [style=dotted, dir=none, label=\"same key\"];"} {
+		if !strings.Contains(dot, s) {
+			t.Errorf("Expected DOT output to contain '%s':\n%s", s, dot)
+		}
+	}
+}
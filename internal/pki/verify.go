@@ -0,0 +1,213 @@
+package pki
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// maxChainDepth bounds candidate path enumeration.
+const maxChainDepth = 10
+
+// VerifyOptions configures VerifyChain. When Roots is empty the system
+// trust store is used.
+type VerifyOptions struct {
+	Roots         []*x509.Certificate
+	Intermediates []*x509.Certificate
+	Host          string
+	KeyUsages     []x509.ExtKeyUsage
+	CurrentTime   time.Time
+}
+
+// ChainPath is one candidate path from the leaf towards a root, with the
+// reason it failed verification (nil when it verified).
+type ChainPath struct {
+	Certs []*x509.Certificate
+	Err   error
+}
+
+// VerifyResult reports every path VerifyChain considered.
+type VerifyResult struct {
+	Leaf      *x509.Certificate
+	Paths     []ChainPath
+	Host      string
+	HostErr   error
+	KeyUsages []string
+	// Err is the outcome of x509.Certificate.Verify over all supplied
+	// certificates; nil means at least one path verified. Neither Err nor
+	// the path errors cover the host, which HostErr reports alone.
+	Err error
+}
+
+// OK reports whether the leaf verified and matched the requested host.
+func (r *VerifyResult) OK() bool {
+	return r.Err == nil && r.HostErr == nil
+}
+
+// VerifyChain verifies leaf with x509.Certificate.Verify and, when explicit
+// roots are given, also enumerates every candidate path by issuer name and
+// key identifier so each one can report its own failure reason.
+func VerifyChain(leaf *x509.Certificate, opts VerifyOptions) *VerifyResult {
+	usages := opts.KeyUsages
+	if len(usages) == 0 {
+		usages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+	res := &VerifyResult{
+		Leaf:      leaf,
+		Host:      opts.Host,
+		KeyUsages: extKeyUsageToStrings(usages),
+	}
+	if opts.Host != "" {
+		res.HostErr = leaf.VerifyHostname(opts.Host)
+	}
+
+	vo := x509.VerifyOptions{
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     usages,
+		CurrentTime:   opts.CurrentTime,
+	}
+	for _, c := range opts.Intermediates {
+		vo.Intermediates.AddCert(c)
+	}
+	if len(opts.Roots) > 0 {
+		vo.Roots = x509.NewCertPool()
+		for _, c := range opts.Roots {
+			vo.Roots.AddCert(c)
+		}
+	}
+
+	chains, err := leaf.Verify(vo)
+	res.Err = err
+
+	if len(opts.Roots) == 0 {
+		// The system pool cannot be enumerated; report what Verify found.
+		for _, chain := range chains {
+			res.Paths = append(res.Paths, ChainPath{Certs: chain})
+		}
+		if err != nil {
+			res.Paths = append(res.Paths, ChainPath{Certs: []*x509.Certificate{leaf}, Err: err})
+		}
+		return res
+	}
+
+	for _, path := range candidatePaths(leaf, opts.Intermediates, opts.Roots) {
+		res.Paths = append(res.Paths, ChainPath{Certs: path, Err: verifyPath(path, opts.Roots, vo)})
+	}
+	return res
+}
+
+// verifyPath checks a single candidate path by restricting Verify to the
+// path's own intermediates and root.
+func verifyPath(path, roots []*x509.Certificate, vo x509.VerifyOptions) error {
+	last := path[len(path)-1]
+	if !containsCert(roots, last) {
+		if isSelfSigned(last) {
+			return fmt.Errorf("chain ends at untrusted self-signed certificate %q", FormatName(last.Subject))
+		}
+		return fmt.Errorf("no issuer found for %q", FormatName(last.Subject))
+	}
+	vo.Roots = x509.NewCertPool()
+	vo.Roots.AddCert(last)
+	// A single-element path means the leaf is itself a trust anchor.
+	vo.Intermediates = nil
+	if len(path) > 1 {
+		vo.Intermediates = x509.NewCertPool()
+		for _, c := range path[1 : len(path)-1] {
+			vo.Intermediates.AddCert(c)
+		}
+	}
+	_, err := path[0].Verify(vo)
+	return err
+}
+
+// candidatePaths walks from leaf through every certificate whose subject
+// matches the current issuer (and whose SKI matches the AKI when both are
+// present) until it reaches a root or runs out of issuers.
+func candidatePaths(leaf *x509.Certificate, intermediates, roots []*x509.Certificate) [][]*x509.Certificate {
+	pool := append(append([]*x509.Certificate{}, roots...), intermediates...)
+	var out [][]*x509.Certificate
+	var walk func(path []*x509.Certificate)
+	walk = func(path []*x509.Certificate) {
+		cur := path[len(path)-1]
+		if containsCert(roots, cur) || isSelfSigned(cur) || len(path) >= maxChainDepth {
+			out = append(out, path)
+			return
+		}
+		found := false
+		for _, cand := range pool {
+			if !issuedBy(cur, cand) || containsCert(path, cand) {
+				continue
+			}
+			found = true
+			next := append(append([]*x509.Certificate{}, path...), cand)
+			walk(next)
+		}
+		if !found {
+			out = append(out, path)
+		}
+	}
+	walk([]*x509.Certificate{leaf})
+	return dedupePaths(out)
+}
+
+// issuedBy reports whether parent's name (and key ID, when both sides carry
+// one) matches child's issuer. Signatures are left to Verify.
+func issuedBy(child, parent *x509.Certificate) bool {
+	if !bytes.Equal(child.RawIssuer, parent.RawSubject) {
+		return false
+	}
+	if len(child.AuthorityKeyId) > 0 && len(parent.SubjectKeyId) > 0 {
+		return bytes.Equal(child.AuthorityKeyId, parent.SubjectKeyId)
+	}
+	return true
+}
+
+// FindIssuer returns the certificate among candidates that issued c and
+// whose key verifies c's signature, or nil.
+func FindIssuer(c *x509.Certificate, candidates []*x509.Certificate) *x509.Certificate {
+	for _, cand := range candidates {
+		if signedBy(c, cand) {
+			return cand
+		}
+	}
+	return nil
+}
+
+// signedBy reports whether parent issued child, checking the signature as
+// well as the names and key IDs issuedBy compares.
+func signedBy(child, parent *x509.Certificate) bool {
+	return !child.Equal(parent) && issuedBy(child, parent) && child.CheckSignatureFrom(parent) == nil
+}
+
+func isSelfSigned(c *x509.Certificate) bool {
+	return bytes.Equal(c.RawIssuer, c.RawSubject) && c.CheckSignature(c.SignatureAlgorithm, c.RawTBSCertificate, c.Signature) == nil
+}
+
+func containsCert(certs []*x509.Certificate, c *x509.Certificate) bool {
+	for _, x := range certs {
+		if x.Equal(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// dedupePaths drops paths that repeat because a certificate was supplied both
+// as a root and as an intermediate.
+func dedupePaths(paths [][]*x509.Certificate) [][]*x509.Certificate {
+	var out [][]*x509.Certificate
+	seen := map[string]bool{}
+	for _, p := range paths {
+		var key bytes.Buffer
+		for _, c := range p {
+			key.Write(c.Raw)
+		}
+		if seen[key.String()] {
+			continue
+		}
+		seen[key.String()] = true
+		out = append(out, p)
+	}
+	return out
+}
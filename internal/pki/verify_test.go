@@ -0,0 +1,119 @@
+package pki
+
+import (
+	"crypto/x509"
+	"testing"
+)
+
+// TestVerifyChainOK tests a leaf -> intermediate -> root chain
+func TestVerifyChainOK(t *testing.T) {
+	root := newTestRoot(t, "Test Root")
+	inter := root.intermediate(t, "Test Intermediate")
+	leaf := inter.leaf(t, "www.example.com")
+
+	res := VerifyChain(leaf, VerifyOptions{
+		Roots:         []*x509.Certificate{root.cert},
+		Intermediates: []*x509.Certificate{inter.cert},
+		Host:          "www.example.com",
+	})
+
+	if !res.OK() {
+		t.Fatalf("Expected verification to succeed, got err=%v hostErr=%v", res.Err, res.HostErr)
+	}
+	if len(res.Paths) != 1 {
+		t.Fatalf("Expected 1 path, got %d", len(res.Paths))
+	}
+	if p := res.Paths[0]; p.Err != nil || len(p.Certs) != 3 {
+		t.Errorf("Expected a verified 3-certificate path, got %d certs, err=%v", len(p.Certs), p.Err)
+	}
+	if len(res.KeyUsages) != 1 || res.KeyUsages[0] != "ServerAuth" {
+		t.Errorf("Expected default EKU ServerAuth, got %v", res.KeyUsages)
+	}
+}
+
+// TestVerifyChainPerPathFailure tests that each candidate path carries its own reason
+func TestVerifyChainPerPathFailure(t *testing.T) {
+	root := newTestRoot(t, "Test Root")
+	inter := root.intermediate(t, "Test Intermediate")
+	leaf := inter.leaf(t, "www.example.com")
+
+	// Missing intermediate: the only path dead-ends at the leaf
+	res := VerifyChain(leaf, VerifyOptions{Roots: []*x509.Certificate{root.cert}})
+	if res.OK() {
+		t.Fatal("Expected verification to fail without the intermediate")
+	}
+	if len(res.Paths) != 1 || res.Paths[0].Err == nil {
+		t.Fatalf("Expected 1 failed path, got %+v", res.Paths)
+	}
+
+	// Wrong EKU
+	res = VerifyChain(leaf, VerifyOptions{
+		Roots:         []*x509.Certificate{root.cert},
+		Intermediates: []*x509.Certificate{inter.cert},
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if res.OK() || res.Paths[0].Err == nil {
+		t.Error("Expected ClientAuth verification to fail")
+	}
+}
+
+// TestVerifyChainHostMismatch tests the hostname check
+func TestVerifyChainHostMismatch(t *testing.T) {
+	root := newTestRoot(t, "Test Root")
+	leaf := root.leaf(t, "www.example.com")
+
+	res := VerifyChain(leaf, VerifyOptions{Roots: []*x509.Certificate{root.cert}, Host: "other.example.com"})
+	if res.HostErr == nil {
+		t.Error("Expected host mismatch")
+	}
+	if res.OK() {
+		t.Error("Expected OK() to be false on host mismatch")
+	}
+	if res.Err != nil || len(res.Paths) != 1 || res.Paths[0].Err != nil {
+		t.Errorf("Expected the path to verify apart from the host, got err=%v paths=%+v", res.Err, res.Paths)
+	}
+}
+
+// TestVerifyChainLeafIsRoot tests a leaf that is itself a trust anchor
+func TestVerifyChainLeafIsRoot(t *testing.T) {
+	root := newTestRoot(t, "Test Root")
+	inter := root.intermediate(t, "Test Intermediate")
+	leaf := inter.leaf(t, "www.example.com")
+
+	res := VerifyChain(leaf, VerifyOptions{Roots: []*x509.Certificate{leaf}, Host: "www.example.com"})
+	if !res.OK() {
+		t.Fatalf("Expected a pinned leaf to verify, got err=%v hostErr=%v", res.Err, res.HostErr)
+	}
+	if len(res.Paths) != 1 || len(res.Paths[0].Certs) != 1 || res.Paths[0].Err != nil {
+		t.Errorf("Expected a single verified 1-certificate path, got %+v", res.Paths)
+	}
+}
+
+// TestParseExtKeyUsage tests EKU name lookup
+func TestParseExtKeyUsage(t *testing.T) {
+	eku, err := ParseExtKeyUsage("clientauth")
+	if err != nil || eku != x509.ExtKeyUsageClientAuth {
+		t.Errorf("ParseExtKeyUsage(clientauth) = %v, %v", eku, err)
+	}
+	if _, err := ParseExtKeyUsage("bogus"); err == nil {
+		t.Error("Expected error for unknown EKU")
+	}
+}
+
+// TestFindIssuer tests issuer lookup by name and signature
+func TestFindIssuer(t *testing.T) {
+	root := newTestRoot(t, "Test Root")
+	inter := root.intermediate(t, "Test Intermediate")
+	leaf := inter.leaf(t, "www.example.com")
+
+	candidates := []*x509.Certificate{leaf, root.cert, inter.cert}
+	if got := FindIssuer(leaf, candidates); got != inter.cert {
+		t.Errorf("Expected the intermediate, got %v", got)
+	}
+	if got := FindIssuer(inter.cert, candidates); got != root.cert {
+		t.Errorf("Expected the root, got %v", got)
+	}
+	if got := FindIssuer(root.cert, candidates); got != nil {
+		t.Errorf("Expected no issuer for the root, got %v", got.Subject)
+	}
+}
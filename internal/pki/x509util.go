@@ -1,18 +1,20 @@
 package pki
 
 import (
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/rsa"
-	"crypto/sha256"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/hex"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/url"
 	"strings"
-	"time"
 )
 
 func ReadPEMBlocks(in []byte) [][]byte {
@@ -35,122 +37,150 @@ func TryParseCert(der []byte) (*x509.Certificate, error) {
 	return x509.ParseCertificate(der)
 }
 
+// ParseCertificates parses every certificate in data, in any encoding
+// DecodeAny accepts. Certificates inside PKCS#7 bundles are included in
+// order; other objects such as keys are ignored. A block that does not
+// parse fails the whole call.
+func ParseCertificates(data []byte) ([]*x509.Certificate, error) {
+	d, err := DecodeAny(data, "")
+	if err != nil {
+		return nil, err
+	}
+	certs, err := d.Certificates()
+	if err != nil {
+		return nil, err
+	}
+	return certs, nil
+}
+
+// Certificates parses the certificates among d's blocks, including those
+// inside PKCS#7 bundles, in order. Blocks that do not parse are skipped
+// and reported in the error, which the certificates that did parse are
+// returned alongside, so callers may render what they can.
+func (d *Decoded) Certificates() ([]*x509.Certificate, error) {
+	var (
+		certs []*x509.Certificate
+		errs  []error
+	)
+	for i, b := range d.Blocks {
+		switch b.Kind {
+		case BlockCertificate:
+			cert, err := TryParseCert(b.Bytes)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("block #%d: %w", i+1, err))
+				continue
+			}
+			certs = append(certs, cert)
+		case BlockPKCS7:
+			p7, err := ParsePKCS7(b.Bytes)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("block #%d: %w", i+1, err))
+				continue
+			}
+			certs = append(certs, p7.Certificates...)
+		}
+	}
+	if len(certs) == 0 && len(errs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+	return certs, errors.Join(errs...)
+}
+
 func PrintCertInfo(c *x509.Certificate) {
 	fmt.Print(GetCertInfoString(c))
 }
 
 func GetCertInfoString(c *x509.Certificate) string {
-	var buf strings.Builder
+	return GetCertInfo(c).Text()
+}
 
-	fmt.Fprintf(&buf, "Subject:             %s\n", nameToOneLine(c.Subject.String()))
-	fmt.Fprintf(&buf, "Issuer:              %s\n", nameToOneLine(c.Issuer.String()))
-	fmt.Fprintf(&buf, "Serial:              %s\n", hexifyBigInt(c.SerialNumber))
-	fmt.Fprintf(&buf, "Version:             %d (X.509v%d)\n", c.Version, c.Version)
-	fmt.Fprintf(&buf, "Signature Algorithm: %s\n", c.SignatureAlgorithm)
-	fmt.Fprintf(&buf, "Public Key:          %s\n", publicKeySummary(c))
-	fmt.Fprintf(&buf, "Validity:\n")
-	fmt.Fprintf(&buf, "  Not Before:        %s\n", c.NotBefore.Format(time.RFC3339))
-	fmt.Fprintf(&buf, "  Not After:         %s\n", c.NotAfter.Format(time.RFC3339))
-	fmt.Fprintf(&buf, "Is CA:               %t\n", c.IsCA)
-	if c.MaxPathLenZero {
-		fmt.Fprintf(&buf, "Path Len:            0 (MaxPathLenZero)\n")
-	} else if c.MaxPathLen > 0 {
-		fmt.Fprintf(&buf, "Path Len:            %d\n", c.MaxPathLen)
-	}
-
-	if ku := keyUsageToStrings(c.KeyUsage); len(ku) > 0 {
-		fmt.Fprintf(&buf, "Key Usage:           %s\n", strings.Join(ku, ", "))
-	}
-	if eku := extKeyUsageToStrings(c.ExtKeyUsage); len(eku) > 0 {
-		fmt.Fprintf(&buf, "Extended Key Usage:  %s\n", strings.Join(eku, ", "))
-	}
-
-	// SANs
-	var sans []string
-	if len(c.DNSNames) > 0 {
-		sans = append(sans, "DNS="+strings.Join(c.DNSNames, ","))
-	}
-	if len(c.EmailAddresses) > 0 {
-		sans = append(sans, "Email="+strings.Join(c.EmailAddresses, ","))
-	}
-	if len(c.IPAddresses) > 0 {
-		var ips []string
-		for _, ip := range c.IPAddresses {
-			ips = append(ips, ip.String())
-		}
-		sans = append(sans, "IP="+strings.Join(ips, ","))
-	}
-	if len(c.URIs) > 0 {
-		var uris []string
-		for _, u := range c.URIs {
-			uris = append(uris, safeURI(u))
+// dnShortNames are the attribute types pkix.Name.String abbreviates;
+// FormatName keeps those abbreviations.
+var dnShortNames = map[string]string{
+	"2.5.4.3":  "CN",
+	"2.5.4.5":  "SERIALNUMBER",
+	"2.5.4.6":  "C",
+	"2.5.4.7":  "L",
+	"2.5.4.8":  "ST",
+	"2.5.4.9":  "STREET",
+	"2.5.4.10": "O",
+	"2.5.4.11": "OU",
+	"2.5.4.17": "POSTALCODE",
+}
+
+// FormatName renders a distinguished name on one line, most specific
+// attribute first, as pkix.Name.String does. Attribute types it has no
+// abbreviation for are named from the OID registry, so that EV subjects
+// read "jurisdictionCountryName=US" rather than a dotted OID and hex;
+// types the registry does not know either are shown as FormatOID gives
+// them. Runs of whitespace in values are squeezed.
+func FormatName(n pkix.Name) string {
+	atvs := n.Names
+	if len(atvs) == 0 {
+		// Built rather than parsed; Names is only filled by parsing.
+		for _, rdn := range n.ToRDNSequence() {
+			atvs = append(atvs, rdn...)
 		}
-		sans = append(sans, "URI="+strings.Join(uris, ","))
-	}
-	if len(sans) > 0 {
-		fmt.Fprintf(&buf, "Subject Alt Names:   %s\n", strings.Join(sans, " | "))
+	} else {
+		atvs = append(atvs, n.ExtraNames...)
 	}
 
-	// IDs
-	if len(c.SubjectKeyId) > 0 {
-		fmt.Fprintf(&buf, "Subject Key ID:      %s\n", hexColon(c.SubjectKeyId))
-	}
-	if len(c.AuthorityKeyId) > 0 {
-		fmt.Fprintf(&buf, "Authority Key ID:    %s\n", hexColon(c.AuthorityKeyId))
+	parts := make([]string, 0, len(atvs))
+	for i := len(atvs) - 1; i >= 0; i-- {
+		atv := atvs[i]
+		oid := atv.Type.String()
+		typ, ok := dnShortNames[oid]
+		if !ok {
+			if typ = OIDName(oid); typ == "" {
+				typ = FormatOID(oid)
+			}
+		}
+		parts = append(parts, typ+"="+formatDNValue(atv.Value))
 	}
+	return strings.Join(parts, ",")
+}
 
-	// AIA / CRL / OCSP
-	if len(c.OCSPServer) > 0 {
-		fmt.Fprintf(&buf, "OCSP:                %s\n", strings.Join(c.OCSPServer, ", "))
-	}
-	if len(c.CRLDistributionPoints) > 0 {
-		fmt.Fprintf(&buf, "CRL Distribution:    %s\n", strings.Join(c.CRLDistributionPoints, ", "))
-	}
-	if len(c.IssuingCertificateURL) > 0 {
-		fmt.Fprintf(&buf, "AIA Issuer URL:      %s\n", strings.Join(c.IssuingCertificateURL, ", "))
-	}
-	if len(c.PolicyIdentifiers) > 0 {
-		var oids []string
-		for _, oid := range c.PolicyIdentifiers {
-			oids = append(oids, oid.String())
+// formatDNValue renders an attribute value with the escaping of RFC 4514,
+// section 2.4; values that are not strings are shown as "#" and the hex of
+// their DER encoding.
+func formatDNValue(v any) string {
+	s, ok := v.(string)
+	if !ok {
+		der, err := asn1.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
 		}
-		fmt.Fprintf(&buf, "Policy OIDs:         %s\n", strings.Join(oids, ", "))
+		return "#" + hex.EncodeToString(der)
 	}
 
-	// Fingerprints
-	sha256fp := sha256.Sum256(c.Raw)
-	fmt.Fprintf(&buf, "Fingerprint SHA-256: %s\n", hexColon(sha256fp[:]))
-
-	// Basic constraints (already partly covered)
-	// Raw extensions (quick peek)
-	if len(c.Extensions) > 0 {
-		var exts []string
-		for _, e := range c.Extensions {
-			critical := ""
-			if e.Critical {
-				critical = " (critical)"
-			}
-			exts = append(exts, fmt.Sprintf("%s%s", e.Id.String(), critical))
+	s = strings.Join(strings.Fields(s), " ")
+	var buf strings.Builder
+	for i, r := range s {
+		switch {
+		case strings.ContainsRune(`,+"\<>;`, r),
+			i == 0 && (r == ' ' || r == '#'),
+			i == len(s)-1 && r == ' ':
+			buf.WriteByte('\\')
 		}
-		fmt.Fprintf(&buf, "Extensions:          %s\n", strings.Join(exts, ", "))
+		buf.WriteRune(r)
 	}
-
-	// Chain-building hints
-	fmt.Fprintf(&buf, "Can Verify Chains:   %t\n", canVerifyChains(c))
-
 	return buf.String()
 }
 
-func nameToOneLine(s string) string {
-	// OpenSSL-style names are comma-separated already; just squeeze whitespace.
-	return strings.Join(strings.Fields(s), " ")
-}
-
 // replace hexifyBigInt and types with:
 func hexifyBigInt(n *big.Int) string {
 	return hexColon(n.Bytes())
 }
 
+// JoinOrNone joins s with commas for the text layout, or says "none" when
+// it is empty.
+func JoinOrNone(s []string) string {
+	if len(s) == 0 {
+		return "none"
+	}
+	return strings.Join(s, ", ")
+}
+
 func hexColon(b []byte) string {
 	s := strings.ToUpper(hex.EncodeToString(b))
 	var buf strings.Builder
@@ -163,8 +193,29 @@ func hexColon(b []byte) string {
 	return buf.String()
 }
 
-func publicKeySummary(c *x509.Certificate) string {
-	switch pub := c.PublicKey.(type) {
+func publicKeyInfo(pub crypto.PublicKey) KeyInfo {
+	ki := KeyInfo{Summary: publicKeySummary(pub)}
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		ki.Algorithm = "RSA"
+		ki.Bits = pub.N.BitLen()
+	case *ecdsa.PublicKey:
+		ki.Algorithm = "ECDSA"
+		if pub.Curve != nil {
+			ki.Bits = pub.Curve.Params().BitSize
+			ki.Curve = pub.Curve.Params().Name
+		}
+	case ed25519.PublicKey:
+		ki.Algorithm = "Ed25519"
+		ki.Bits = 256
+	default:
+		ki.Algorithm = fmt.Sprintf("%T", pub)
+	}
+	return ki
+}
+
+func publicKeySummary(pub crypto.PublicKey) string {
+	switch pub := pub.(type) {
 	case *rsa.PublicKey:
 		return fmt.Sprintf("RSA (%d bits)", pub.N.BitLen())
 	case *ecdsa.PublicKey:
@@ -198,24 +249,38 @@ func keyUsageToStrings(ku x509.KeyUsage) []string {
 	return out
 }
 
-func extKeyUsageToStrings(eku []x509.ExtKeyUsage) []string {
-	names := map[x509.ExtKeyUsage]string{
-		x509.ExtKeyUsageAny:                        "Any",
-		x509.ExtKeyUsageServerAuth:                 "ServerAuth",
-		x509.ExtKeyUsageClientAuth:                 "ClientAuth",
-		x509.ExtKeyUsageCodeSigning:                "CodeSigning",
-		x509.ExtKeyUsageEmailProtection:            "EmailProtection",
-		x509.ExtKeyUsageIPSECEndSystem:             "IPSECEndSystem",
-		x509.ExtKeyUsageIPSECTunnel:                "IPSECTunnel",
-		x509.ExtKeyUsageIPSECUser:                  "IPSECUser",
-		x509.ExtKeyUsageTimeStamping:               "TimeStamping",
-		x509.ExtKeyUsageOCSPSigning:                "OCSPSigning",
-		x509.ExtKeyUsageMicrosoftServerGatedCrypto: "MS SGC",
-		x509.ExtKeyUsageNetscapeServerGatedCrypto:  "Netscape SGC",
+var extKeyUsageNames = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:                            "Any",
+	x509.ExtKeyUsageServerAuth:                     "ServerAuth",
+	x509.ExtKeyUsageClientAuth:                     "ClientAuth",
+	x509.ExtKeyUsageCodeSigning:                    "CodeSigning",
+	x509.ExtKeyUsageEmailProtection:                "EmailProtection",
+	x509.ExtKeyUsageIPSECEndSystem:                 "IPSECEndSystem",
+	x509.ExtKeyUsageIPSECTunnel:                    "IPSECTunnel",
+	x509.ExtKeyUsageIPSECUser:                      "IPSECUser",
+	x509.ExtKeyUsageTimeStamping:                   "TimeStamping",
+	x509.ExtKeyUsageOCSPSigning:                    "OCSPSigning",
+	x509.ExtKeyUsageMicrosoftServerGatedCrypto:     "MS SGC",
+	x509.ExtKeyUsageNetscapeServerGatedCrypto:      "Netscape SGC",
+	x509.ExtKeyUsageMicrosoftCommercialCodeSigning: "MS Commercial Code Signing",
+	x509.ExtKeyUsageMicrosoftKernelCodeSigning:     "MS Kernel Code Signing",
+}
+
+// ParseExtKeyUsage looks up an extended key usage by the name used in the
+// certinfo output (e.g. "ServerAuth"), ignoring case.
+func ParseExtKeyUsage(name string) (x509.ExtKeyUsage, error) {
+	for eku, s := range extKeyUsageNames {
+		if strings.EqualFold(s, name) {
+			return eku, nil
+		}
 	}
+	return 0, fmt.Errorf("unknown extended key usage %q", name)
+}
+
+func extKeyUsageToStrings(eku []x509.ExtKeyUsage) []string {
 	var out []string
 	for _, e := range eku {
-		if s, ok := names[e]; ok {
+		if s, ok := extKeyUsageNames[e]; ok {
 			out = append(out, s)
 		} else {
 			out = append(out, fmt.Sprintf("Unknown(%d)", e))
@@ -1,10 +1,14 @@
 package pki
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/pem"
 	"math/big"
 	"strings"
@@ -88,6 +92,37 @@ func TestGetCertInfoString(t *testing.T) {
 	}
 }
 
+// TestFormatName tests that DN attribute types are named from the OID
+// registry
+func TestFormatName(t *testing.T) {
+	root := newTestRoot(t, "Name Root")
+	cert, _ := root.issue(t, &x509.Certificate{
+		Subject: pkix.Name{
+			Country:      []string{"US"},
+			Organization: []string{"Example, Inc."},
+			CommonName:   "ev.example.com",
+			SerialNumber: "5157550",
+			ExtraNames: []pkix.AttributeTypeAndValue{
+				{Type: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 60, 2, 1, 3}, Value: "US"},
+				{Type: asn1.ObjectIdentifier{2, 5, 4, 15}, Value: "Private Organization"},
+				{Type: asn1.ObjectIdentifier{1, 2, 3, 4}, Value: "x"},
+			},
+		},
+	})
+
+	expected := `1.2.3.4=x,businessCategory=Private Organization,jurisdictionCountryName=US,` +
+		`SERIALNUMBER=5157550,CN=ev.example.com,O=Example\, Inc.,C=US`
+	if got := FormatName(cert.Subject); got != expected {
+		t.Errorf("FormatName() = %q, expected %q", got, expected)
+	}
+	if got := GetCertInfo(cert).Subject; got != expected {
+		t.Errorf("Expected the certificate subject rendered by FormatName, got %q", got)
+	}
+	if got := FormatName(pkix.Name{CommonName: " a  b ", OrganizationalUnit: []string{"#1"}}); got != `CN=a b,OU=\#1` {
+		t.Errorf("Expected squeezed and escaped values, got %q", got)
+	}
+}
+
 // TestHexColon tests the hexColon helper function
 func TestHexColon(t *testing.T) {
 	testCases := []struct {
@@ -143,3 +178,87 @@ func createTestCertificate(t *testing.T) *x509.Certificate {
 
 	return cert
 }
+
+// testIssuer is a CA certificate and key used to sign test certificates
+type testIssuer struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+// newTestRoot creates a self-signed ECDSA root CA
+func newTestRoot(t *testing.T, cn string) *testIssuer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create root: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse root: %v", err)
+	}
+	return &testIssuer{cert: cert, key: key}
+}
+
+// issue signs template with the issuer and returns the certificate and its new key
+func (ti *testIssuer) issue(t *testing.T, template *x509.Certificate) (*x509.Certificate, crypto.Signer) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	if template.SerialNumber == nil {
+		template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	}
+	if template.NotBefore.IsZero() {
+		template.NotBefore = time.Now().Add(-time.Hour)
+	}
+	if template.NotAfter.IsZero() {
+		template.NotAfter = time.Now().Add(12 * time.Hour)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ti.cert, &key.PublicKey, ti.key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert, key
+}
+
+// intermediate issues a CA certificate and returns it as a new issuer
+func (ti *testIssuer) intermediate(t *testing.T, cn string) *testIssuer {
+	t.Helper()
+	cert, key := ti.issue(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: cn},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	})
+	return &testIssuer{cert: cert, key: key}
+}
+
+// leaf issues a TLS server certificate for the given DNS names
+func (ti *testIssuer) leaf(t *testing.T, dnsNames ...string) *x509.Certificate {
+	t.Helper()
+	cert, _ := ti.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:    dnsNames,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	return cert
+}